@@ -2,82 +2,154 @@ package application
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
-	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/oupo1337/velibs/backend/common/config"
 	"github.com/oupo1337/velibs/backend/common/logging"
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/common/tracing"
 )
 
+// Service runs until it is stopped. Start blocks while the service runs and
+// calls ready once the service is up, such as when a server listens.
 type Service interface {
-	Start() error
+	Start(ready func()) error
 	Stop(ctx context.Context) error
 }
 
+// Closer releases a resource, such as a connection pool or an exporter, once
+// every service has stopped.
+type Closer func(ctx context.Context) error
+
+type closer struct {
+	name  string
+	close Closer
+}
+
 type Application struct {
 	Name    string
 	Version string
 	Address string
 
-	services []Service
+	shutdownTimeout time.Duration
+	services        []Service
+	started         int
+	closers         []closer
 }
 
-func New(serviceName string) Application {
-	address := os.Getenv("APP_ADDR")
+func New(serviceName string, conf config.Config) (*Application, error) {
+	if err := logging.Init(serviceName, conf.Logging); err != nil {
+		return nil, fmt.Errorf("logging.Init error: %w", err)
+	}
+	metrics.Init()
 
-	logging.Init(serviceName)
-	if err := tracing.Init(serviceName); err != nil {
-		slog.Error("tracing.Init error", slog.String("error", err.Error()))
-		os.Exit(1)
+	app := &Application{
+		Name:            serviceName,
+		Address:         conf.Application.Address,
+		shutdownTimeout: conf.Application.ShutdownTimeout,
 	}
 
-	return Application{
-		Name:    serviceName,
-		Address: address,
+	shutdown, err := tracing.Init(serviceName, conf.Telemetry)
+	if err != nil {
+		return nil, fmt.Errorf("tracing.Init error: %w", err)
 	}
+	app.AddCloser("tracing", shutdown)
+	return app, nil
 }
 
+// AddServices registers services, they are started in the given order, each
+// one once the previous is ready, and stopped in the reverse one.
 func (app *Application) AddServices(s ...Service) {
 	app.services = append(app.services, s...)
 }
 
-func (app *Application) start() {
+// AddCloser registers a resource closed after the services, in the reverse
+// order of registration.
+func (app *Application) AddCloser(name string, c Closer) {
+	app.closers = append(app.closers, closer{name: name, close: c})
+}
+
+// start starts the services one after the other, until ctx is done or one of
+// them fails. The services failing once all are started report to errs.
+func (app *Application) start(ctx context.Context, errs chan error) error {
 	for _, service := range app.services {
+		ready := make(chan struct{})
+		var once sync.Once
+		markReady := func() {
+			once.Do(func() { close(ready) })
+		}
+
+		app.started++
 		go func(s Service) {
-			if err := s.Start(); err != nil {
-				slog.Error("service.Run error", slog.String("error", err.Error()))
-				os.Exit(1)
+			defer markReady()
+			if err := s.Start(markReady); err != nil {
+				errs <- fmt.Errorf("service.Start error: %w", err)
 			}
 		}(service)
+
+		select {
+		case <-ready:
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
 	}
+	return nil
 }
 
-func (app *Application) stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// stop stops the services that were started in the reverse order, then closes
+// the resources, all within the shutdown timeout.
+func (app *Application) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), app.shutdownTimeout)
 	defer cancel()
 
-	for _, service := range app.services {
+	var errs []error
+	for _, service := range slices.Backward(app.services[:app.started]) {
 		if err := service.Stop(ctx); err != nil {
-			slog.Error("service.Stop error", slog.String("error", err.Error()))
-			os.Exit(1)
+			errs = append(errs, fmt.Errorf("service.Stop error: %w", err))
+		}
+	}
+
+	for _, c := range slices.Backward(app.closers) {
+		if err := c.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close %s error: %w", c.name, err))
 		}
 	}
+	return errors.Join(errs...)
 }
 
-func (app *Application) Run() {
+// Run starts the services and blocks until a termination signal is received
+// or one of them fails, then shuts everything down.
+func (app *Application) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	app.start()
-	slog.Info("service is running")
-	<-ctx.Done()
+	var errs []error
+	startErrs := make(chan error, len(app.services))
+	if err := app.start(ctx, startErrs); err != nil {
+		errs = append(errs, err)
+	} else if ctx.Err() == nil {
+		slog.Info("service is running")
+
+		select {
+		case <-ctx.Done():
+		case err := <-startErrs:
+			errs = append(errs, err)
+		}
+	}
 
 	stop()
-	slog.Info("shutting down gracefully, press Ctrl+C again to force")
+	slog.Info("shutting down gracefully, press Ctrl+C again to force", slog.Duration("timeout", app.shutdownTimeout))
 
-	app.stop()
-	slog.Info("serivce is exiting")
+	errs = append(errs, app.stop())
+	slog.Info("service is exiting")
+	return errors.Join(errs...)
 }
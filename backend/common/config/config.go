@@ -0,0 +1,284 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is loaded, in increasing order of precedence, from the `default`
+// tags, the YAML file pointed by CONFIG_FILE, the environment variables named
+// by the `env` tags and the files pointed by the same variables suffixed with
+// _FILE, which is how secrets are mounted.
+type Config struct {
+	Application Application `yaml:"application"`
+	Database    Database    `yaml:"database"`
+	Logging     Logging     `yaml:"logging"`
+	Telemetry   Telemetry   `yaml:"telemetry"`
+	Fetcher     Fetcher     `yaml:"fetcher"`
+	API         API         `yaml:"api"`
+}
+
+// localOrigin is the frontend run locally, always allowed by CORS.
+const localOrigin = "http://localhost:3000"
+
+type Application struct {
+	Address string `yaml:"address" env:"APPLICATION_ADDRESS" default:":8080"`
+
+	// DomainName is the origin of the frontend, CORSOrigins lists the other
+	// origins allowed next to it and to localOrigin.
+	DomainName  string   `yaml:"domain_name" env:"APPLICATION_DOMAIN_NAME"`
+	CORSOrigins []string `yaml:"cors_origins" env:"APPLICATION_CORS_ORIGINS"`
+
+	// ShutdownTimeout bounds the time given to the services to drain and
+	// to the resources to be closed once a termination signal is received.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"APPLICATION_SHUTDOWN_TIMEOUT" default:"15s"`
+}
+
+// Origins returns the origins allowed by CORS, without duplicates.
+func (a Application) Origins() []string {
+	origins := []string{localOrigin}
+	for _, origin := range append(a.CORSOrigins, a.DomainName) {
+		if origin != "" && !slices.Contains(origins, origin) {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+type Database struct {
+	Username         string        `yaml:"username" env:"DATABASE_USERNAME" required:"true"`
+	Password         string        `yaml:"password" env:"DATABASE_PASSWORD" required:"true"`
+	Address          string        `yaml:"address" env:"DATABASE_ADDRESS" required:"true"`
+	Name             string        `yaml:"name" env:"DATABASE_NAME" required:"true"`
+	ReadAddress      string        `yaml:"read_address" env:"DATABASE_READ_ADDRESS"`
+	SSLMode          string        `yaml:"ssl_mode" env:"DATABASE_SSL_MODE" default:"prefer"`
+	SSLRootCert      string        `yaml:"ssl_root_cert" env:"DATABASE_SSL_ROOT_CERT"`
+	SSLCert          string        `yaml:"ssl_cert" env:"DATABASE_SSL_CERT"`
+	SSLKey           string        `yaml:"ssl_key" env:"DATABASE_SSL_KEY"`
+	MinConns         int32         `yaml:"min_conns" env:"DATABASE_MIN_CONNS" default:"0"`
+	MaxConns         int32         `yaml:"max_conns" env:"DATABASE_MAX_CONNS" default:"10"`
+	MaxConnIdleTime  time.Duration `yaml:"max_conn_idle_time" env:"DATABASE_MAX_CONN_IDLE_TIME" default:"30m"`
+	MaxConnLifetime  time.Duration `yaml:"max_conn_lifetime" env:"DATABASE_MAX_CONN_LIFETIME" default:"1h"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"DATABASE_STATEMENT_TIMEOUT" default:"0s"`
+	ApplicationName  string        `yaml:"application_name" env:"DATABASE_APPLICATION_NAME"`
+}
+
+type Logging struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+type Telemetry struct {
+	Enabled        bool          `yaml:"enabled" env:"TELEMETRY_ENABLED" default:"false"`
+	Endpoint       string        `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	SamplingRatio  float64       `yaml:"sampling_ratio" env:"TELEMETRY_SAMPLING_RATIO" default:"1"`
+	MetricInterval time.Duration `yaml:"metric_interval" env:"TELEMETRY_METRIC_INTERVAL" default:"30s"`
+
+	// MetricsEndpoint is the full URL metrics are pushed to, when they
+	// don't go to the same collector as the traces.
+	MetricsEndpoint string `yaml:"metrics_endpoint" env:"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"`
+}
+
+type Fetcher struct {
+	Schedules Schedules `yaml:"schedules"`
+	Feeds     Feeds     `yaml:"feeds"`
+	Alerts    Alerts    `yaml:"alerts"`
+}
+
+type Schedules struct {
+	Statuses          string `yaml:"statuses" env:"SCHEDULE_STATUSES" default:"0 */10 * * * *"`
+	FreeFloatingBikes string `yaml:"free_floating_bikes" env:"SCHEDULE_FREE_FLOATING_BIKES" default:"0 */10 * * * *"`
+	Stations          string `yaml:"stations" env:"SCHEDULE_STATIONS" default:"0 0 0 * * *"`
+	BikeLanes         string `yaml:"bike_lanes" env:"SCHEDULE_BIKE_LANES" default:"0 0 0 * * *"`
+	AlertDeliveries   string `yaml:"alert_deliveries" env:"SCHEDULE_ALERT_DELIVERIES" default:"30 * * * * *"`
+}
+
+type Feeds struct {
+	Statuses                string `yaml:"statuses" env:"FEED_STATUSES_URL" default:"https://velib-metropole-opendata.smovengo.cloud/opendata/Velib_Metropole/station_status.json"`
+	Stations                string `yaml:"stations" env:"FEED_STATIONS_URL" default:"https://velib-metropole-opendata.smovengo.cloud/opendata/Velib_Metropole/station_information.json"`
+	FreeFloatingBikes       string `yaml:"free_floating_bikes" env:"FEED_FREE_FLOATING_BIKES_URL" default:"https://data.lime.bike/api/partners/v2/gbfs/paris/free_bike_status"`
+	BikeLanes               string `yaml:"bike_lanes" env:"FEED_BIKE_LANES_URL" default:"https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/amenagements-cyclables/exports/geojson?lang=fr&timezone=Europe%2FBerlin"`
+	Boroughs                string `yaml:"boroughs" env:"FEED_BOROUGHS_URL" default:"https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/arrondissements/exports/geojson?lang=fr&timezone=Europe%2FBerlin"`
+	AdministrativeDistricts string `yaml:"administrative_districts" env:"FEED_ADMINISTRATIVE_DISTRICTS_URL" default:"https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/quartier_paris/exports/geojson?lang=fr&timezone=Europe%2FBerlin"`
+
+	// Communes is an http(s) URL or the path of a local GeoJSON file, by
+	// default the communes of Île-de-France from the API Géo of the state,
+	// drawn from IGN ADMIN EXPRESS.
+	Communes string `yaml:"communes" env:"FEED_COMMUNES_URL" default:"https://geo.api.gouv.fr/communes?codeRegion=11&format=geojson&geometry=contour&fields=code,nom"`
+}
+
+type API struct {
+	Alerts AlertsAPI `yaml:"alerts"`
+}
+
+type AlertsAPI struct {
+	// Keys are the "owner:key" pairs allowed to manage alerts, the alert
+	// routes refuse every request when there are none.
+	Keys         []string      `yaml:"keys" env:"ALERTS_API_KEYS"`
+	MaxActive    int           `yaml:"max_active" env:"ALERTS_MAX_ACTIVE" default:"20"`
+	CreateLimit  int           `yaml:"create_limit" env:"ALERTS_CREATE_LIMIT" default:"10"`
+	CreateWindow time.Duration `yaml:"create_window" env:"ALERTS_CREATE_WINDOW" default:"1h"`
+}
+
+type Alerts struct {
+	Timeout     time.Duration `yaml:"timeout" env:"ALERTS_TIMEOUT" default:"10s"`
+	MaxAttempts int           `yaml:"max_attempts" env:"ALERTS_MAX_ATTEMPTS" default:"6"`
+
+	// AllowPrivateWebhooks lets webhooks target loopback and private
+	// addresses, which is only wanted in development.
+	AllowPrivateWebhooks bool `yaml:"allow_private_webhooks" env:"ALERTS_ALLOW_PRIVATE_WEBHOOKS" default:"false"`
+}
+
+func Load() (Config, error) {
+	var conf Config
+	if err := walk(reflect.ValueOf(&conf).Elem(), applyDefault); err != nil {
+		return Config{}, err
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: os.ReadFile error: %w", err)
+		}
+
+		if err := yaml.Unmarshal(data, &conf); err != nil {
+			return Config{}, fmt.Errorf("config: yaml.Unmarshal %s error: %w", path, err)
+		}
+	}
+
+	if err := walk(reflect.ValueOf(&conf).Elem(), applyEnv); err != nil {
+		return Config{}, err
+	}
+
+	if err := walk(reflect.ValueOf(&conf).Elem(), checkRequired); err != nil {
+		return Config{}, err
+	}
+	return conf, nil
+}
+
+func walk(v reflect.Value, apply func(field reflect.StructField, value reflect.Value) error) error {
+	var errs []error
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Struct {
+			if err := walk(value, apply); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := apply(field, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func applyDefault(field reflect.StructField, value reflect.Value) error {
+	raw, ok := field.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+
+	if err := set(value, raw); err != nil {
+		return fmt.Errorf("config: invalid default for %s: %w", field.Name, err)
+	}
+	return nil
+}
+
+func applyEnv(field reflect.StructField, value reflect.Value) error {
+	name := field.Tag.Get("env")
+	if name == "" {
+		return nil
+	}
+
+	raw, ok := os.LookupEnv(name)
+	if path, isFile := os.LookupEnv(name + "_FILE"); isFile {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("config: cannot read %s_FILE: %w", name, err)
+		}
+		raw, ok = strings.TrimSpace(string(data)), true
+	}
+	if !ok {
+		return nil
+	}
+
+	if err := set(value, raw); err != nil {
+		return fmt.Errorf("config: invalid value for %s: %w", name, err)
+	}
+	return nil
+}
+
+func checkRequired(field reflect.StructField, value reflect.Value) error {
+	if field.Tag.Get("required") != "true" || !value.IsZero() {
+		return nil
+	}
+
+	if name := field.Tag.Get("env"); name != "" {
+		return fmt.Errorf("config: %s is required", name)
+	}
+	return fmt.Errorf("config: %s is required", field.Name)
+}
+
+func set(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		if raw == "" {
+			value.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", value.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
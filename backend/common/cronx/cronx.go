@@ -2,51 +2,330 @@ package cronx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/common/tracing"
 )
 
+type Locker interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	LeaseHolder(ctx context.Context, name string) (string, error)
+}
+
+type OverlapPolicy int
+
+const (
+	AllowOverlap OverlapPolicy = iota
+	SkipIfRunning
+	QueueIfRunning
+)
+
 type Cron struct {
-	cron *cron.Cron
+	cron     *cron.Cron
+	locker   Locker
+	identity string
+	jobs     []*job
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.Mutex
+	stopped     bool
+	running     sync.WaitGroup
+	lastSuccess map[string]time.Time
+}
+
+type job struct {
+	name     string
+	cmd      func(ctx context.Context) error
+	leaseTTL time.Duration
+	timeout  time.Duration
+	jitter   time.Duration
+	overlap  OverlapPolicy
+	mu       sync.Mutex
+}
+
+type Option func(*Cron)
+
+func WithLocker(locker Locker, identity string) Option {
+	return func(c *Cron) {
+		c.locker = locker
+		c.identity = identity
+	}
+}
+
+type JobOption func(*job)
+
+// WithLease makes the job run on a single replica: the replica holding the
+// lease keeps renewing it on every run, the others skip until it expires.
+func WithLease(ttl time.Duration) JobOption {
+	return func(j *job) {
+		j.leaseTTL = ttl
+	}
+}
+
+func WithTimeout(timeout time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = timeout
+	}
+}
+
+// WithJitter delays every run by a random duration up to max, so that jobs
+// sharing a schedule don't all hit upstream feeds at the same second.
+func WithJitter(max time.Duration) JobOption {
+	return func(j *job) {
+		j.jitter = max
+	}
+}
+
+func WithOverlapPolicy(policy OverlapPolicy) JobOption {
+	return func(j *job) {
+		j.overlap = policy
+	}
+}
+
+func (c *Cron) leased(j *job) bool {
+	return c.locker != nil && j.leaseTTL > 0
+}
+
+func (c *Cron) track() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return false
+	}
+	c.running.Add(1)
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+const (
+	outcomeSuccess   = "success"
+	outcomeFailure   = "failure"
+	outcomePanic     = "panic"
+	outcomeSkipped   = "skipped"
+	outcomeFollower  = "follower"
+	outcomeCancelled = "cancelled"
+)
+
+func (c *Cron) run(j *job) {
+	if !c.track() {
+		return
+	}
+	defer c.running.Done()
+
+	ctx, span := tracing.Start(c.ctx, j.name)
+	defer span.End()
+
+	start := time.Now()
+	outcome := outcomePanic
+	defer func() {
+		metrics.ObserveJob(j.name, outcome, time.Since(start))
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			span.SetStatus(codes.Error, "panic")
+			slog.ErrorContext(ctx, "panic", slog.Any("error", err))
+		}
+	}()
+
+	outcome = c.execute(ctx, span, j)
+	if outcome == outcomeSuccess || outcome == outcomeFollower {
+		c.mu.Lock()
+		c.lastSuccess[j.name] = time.Now()
+		c.mu.Unlock()
+	}
 }
 
-func (c *Cron) AddFunc(spec, name string, cmd func(ctx context.Context) error) error {
-	return c.cron.AddFunc(spec, func() {
-		ctx, span := tracing.Start(context.Background(), name)
-		defer span.End()
+// LastSuccess returns when the job last completed, either on this replica or,
+// for leased jobs, by deferring to the leader. Jobs count as fresh when the
+// scheduler starts.
+func (c *Cron) LastSuccess(name string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		defer func() {
-			if err := recover(); err != nil {
-				span.SetStatus(codes.Error, "panic")
-				slog.ErrorContext(ctx, "panic", slog.Any("error", err))
-			}
-		}()
+	return c.lastSuccess[name]
+}
+
+func (c *Cron) execute(ctx context.Context, span trace.Span, j *job) string {
+	if j.jitter > 0 {
+		if err := sleep(ctx, rand.N(j.jitter)); err != nil {
+			slog.InfoContext(ctx, fmt.Sprintf("%s cancelled before start", j.name))
+			return outcomeCancelled
+		}
+	}
+
+	switch j.overlap {
+	case SkipIfRunning:
+		if !j.mu.TryLock() {
+			span.SetAttributes(attribute.Bool("cron.skipped", true))
+			slog.WarnContext(ctx, fmt.Sprintf("%s skipped, previous run still in progress", j.name))
+			return outcomeSkipped
+		}
+		defer j.mu.Unlock()
+	case QueueIfRunning:
+		j.mu.Lock()
+		defer j.mu.Unlock()
+	}
+
+	if ctx.Err() != nil {
+		slog.InfoContext(ctx, fmt.Sprintf("%s cancelled before start", j.name))
+		return outcomeCancelled
+	}
 
-		if err := cmd(ctx); err != nil {
-			span.SetStatus(codes.Error, fmt.Sprintf("%s failed", name))
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	if c.leased(j) {
+		acquired, err := c.locker.AcquireLease(ctx, j.name, c.identity, j.leaseTTL)
+		if err != nil {
+			span.SetStatus(codes.Error, "lease acquisition failed")
 			span.RecordError(err)
-			slog.ErrorContext(ctx, fmt.Sprintf("%s failed", name), slog.String("error", err.Error()))
+			slog.ErrorContext(ctx, fmt.Sprintf("%s lease acquisition failed", j.name), slog.String("error", err.Error()))
+			return outcomeFailure
+		}
+
+		span.SetAttributes(attribute.Bool("cron.leader", acquired))
+		if !acquired {
+			slog.DebugContext(ctx, fmt.Sprintf("%s skipped, lease held by another replica", j.name))
+			return outcomeFollower
+		}
+	}
+
+	if err := j.cmd(ctx); err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s failed", j.name))
+		span.RecordError(err)
+		slog.ErrorContext(ctx, fmt.Sprintf("%s failed", j.name), slog.String("error", err.Error()))
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
+
+func (c *Cron) AddFunc(spec, name string, cmd func(ctx context.Context) error, options ...JobOption) error {
+	j := &job{
+		name: name,
+		cmd:  cmd,
+	}
+
+	for _, option := range options {
+		option(j)
+	}
+
+	if err := c.cron.AddFunc(spec, func() { c.run(j) }); err != nil {
+		return err
+	}
+	c.jobs = append(c.jobs, j)
+	return nil
+}
+
+func (c *Cron) Identity() string {
+	return c.identity
+}
+
+// Leaders returns, for every leased job, the replica currently holding its lease.
+func (c *Cron) Leaders(ctx context.Context) (map[string]string, error) {
+	leaders := make(map[string]string)
+	for _, j := range c.jobs {
+		if !c.leased(j) {
+			continue
 		}
-	})
+
+		holder, err := c.locker.LeaseHolder(ctx, j.name)
+		if err != nil {
+			return nil, fmt.Errorf("locker.LeaseHolder error: %w", err)
+		}
+		leaders[j.name] = holder
+	}
+	return leaders, nil
 }
 
-func (c *Cron) Start() error {
+// Start is ready once the jobs are scheduled.
+func (c *Cron) Start(ready func()) error {
+	c.mu.Lock()
+	now := time.Now()
+	for _, j := range c.jobs {
+		c.lastSuccess[j.name] = now
+	}
+	c.mu.Unlock()
+
+	ready()
 	c.cron.Run()
 	return nil
 }
 
-func (c *Cron) Stop(_ context.Context) error {
+// Stop prevents new runs, cancels the in-flight ones and waits for them to
+// return until ctx expires.
+func (c *Cron) Stop(ctx context.Context) error {
 	c.cron.Stop()
-	return nil
+
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.cancel()
+
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("waiting for running jobs: %w", ctx.Err()))
+	}
+
+	for _, j := range c.jobs {
+		if !c.leased(j) {
+			continue
+		}
+
+		if err := c.locker.ReleaseLease(ctx, j.name, c.identity); err != nil {
+			errs = append(errs, fmt.Errorf("locker.ReleaseLease error: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func New() *Cron {
-	return &Cron{
-		cron: cron.New(),
+func New(options ...Option) *Cron {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cron{
+		cron:        cron.New(),
+		ctx:         ctx,
+		cancel:      cancel,
+		lastSuccess: make(map[string]time.Time),
+	}
+
+	for _, option := range options {
+		option(c)
 	}
+	return c
 }
@@ -0,0 +1,123 @@
+package geojson
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	ContentType    = "application/geo+json"
+	SeqContentType = "application/geo+json-seq"
+
+	recordSeparator = 0x1e
+)
+
+// Writer streams features as they come, either wrapped in a FeatureCollection
+// or as a GeoJSON text sequence (RFC 8142), one feature per record.
+type Writer struct {
+	w       *bufio.Writer
+	seq     bool
+	members map[string]any
+	started bool
+	count   int
+}
+
+type Option func(*Writer)
+
+// WithSequence writes a GeoJSON text sequence instead of a FeatureCollection.
+func WithSequence(seq bool) Option {
+	return func(w *Writer) {
+		w.seq = seq
+	}
+}
+
+// WithMember adds a foreign member to the FeatureCollection, it is ignored by
+// text sequences which have no enclosing object.
+func WithMember(name string, value any) Option {
+	return func(w *Writer) {
+		w.members[name] = value
+	}
+}
+
+func NewWriter(w io.Writer, options ...Option) *Writer {
+	writer := &Writer{
+		w:       bufio.NewWriter(w),
+		members: make(map[string]any),
+	}
+
+	for _, option := range options {
+		option(writer)
+	}
+	return writer
+}
+
+func (w *Writer) start() error {
+	w.started = true
+	if w.seq {
+		return nil
+	}
+
+	if _, err := w.w.WriteString(`{"type":"FeatureCollection",`); err != nil {
+		return err
+	}
+	for name, value := range w.members {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("json.Marshal %s error: %w", name, err)
+		}
+		if _, err := fmt.Fprintf(w.w, "%q:%s,", name, data); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.WriteString(`"features":[`)
+	return err
+}
+
+// WriteFeature writes a feature already encoded as JSON.
+func (w *Writer) WriteFeature(feature []byte) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case w.seq:
+		if err := w.w.WriteByte(recordSeparator); err != nil {
+			return err
+		}
+	case w.count > 0:
+		if err := w.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.w.Write(feature); err != nil {
+		return err
+	}
+	if w.seq {
+		if err := w.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	w.count++
+	return nil
+}
+
+// Close ends the FeatureCollection and flushes what is still buffered.
+func (w *Writer) Close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	if !w.seq {
+		if _, err := w.w.WriteString("]}"); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
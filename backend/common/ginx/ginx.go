@@ -3,32 +3,45 @@ package ginx
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"os"
 	"slices"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
+	"github.com/oupo1337/velibs/backend/common/config"
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/common/middleware"
+	"github.com/oupo1337/velibs/backend/domain"
 )
 
 type Engine struct {
 	*gin.Engine
-	srv *http.Server
+	srv    *http.Server
+	checks map[string]Check
 }
 
 func healtcheck(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
-func allowOrigins(origin string) bool {
-	return origin == "http://localhost:3000" || origin == os.Getenv("APPLICATION_DOMAIN_NAME")
-}
+// Start is ready once the server listens.
+func (e *Engine) Start(ready func()) error {
+	address := e.srv.Addr
+	if address == "" {
+		address = ":http"
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("net.Listen error: %w", err)
+	}
+	ready()
 
-func (e *Engine) Start() error {
-	err := e.srv.ListenAndServe()
+	err = e.srv.Serve(listener)
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -39,31 +52,51 @@ func (e *Engine) Stop(ctx context.Context) error {
 	return e.srv.Shutdown(ctx)
 }
 
-func New(serviceName string) *Engine {
+func New(serviceName string, conf config.Application) *Engine {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
-	skip := []string{"/"}
+	skip := []string{"/", "/livez", "/readyz", "/metrics"}
 
 	config := cors.Config{
-		AllowOriginFunc: allowOrigins,
-		AllowMethods:    []string{http.MethodHead, http.MethodOptions, http.MethodGet},
-		AllowHeaders:    []string{"E-Tag", "If-None-Match"},
-		ExposeHeaders:   []string{"E-Tag"},
+		AllowOrigins:  conf.Origins(),
+		AllowMethods:  []string{http.MethodHead, http.MethodOptions, http.MethodGet, http.MethodPost, http.MethodDelete},
+		AllowHeaders:  []string{"Authorization", "Content-Type", "E-Tag", "If-None-Match", "Last-Event-ID", middleware.RequestIDHeader},
+		ExposeHeaders: []string{"E-Tag", "Location", "Retry-After", middleware.RequestIDHeader, "X-Snapshot-Timestamp"},
 	}
 
-	engine.Use(gin.Recovery())
+	engine.Use(middleware.NewRequestID())
 	engine.Use(middleware.NewLogging(middleware.WithIgnorePath(skip)))
+	engine.Use(middleware.NewMetrics(middleware.WithIgnorePath(skip)))
+	engine.Use(middleware.NewRecovery())
+	engine.Use(middleware.NewCompression(middleware.WithIgnorePath(skip)))
 	engine.Use(cors.New(config))
 	engine.Use(otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
 		return !slices.Contains(skip, r.URL.Path)
 	})))
+	engine.Use(middleware.NewErrors(
+		middleware.WithErrorStatus(domain.ErrNotFound, http.StatusNotFound, "not_found"),
+		middleware.WithErrorStatus(domain.ErrInvalidTimestamp, http.StatusBadRequest, "invalid_timestamp"),
+		middleware.WithErrorStatus(domain.ErrTimeout, http.StatusServiceUnavailable, "timeout"),
+		middleware.WithErrorStatus(domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"),
+		middleware.WithErrorStatus(domain.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"),
+		middleware.WithErrorStatus(domain.ErrLimitReached, http.StatusForbidden, "limit_reached"),
+	))
+	engine.NoRoute(func(c *gin.Context) {
+		_ = c.Error(domain.ErrNotFound)
+	})
 
-	engine.GET("/", healtcheck)
-	return &Engine{
+	e := &Engine{
 		Engine: engine,
 		srv: &http.Server{
-			Addr:    os.Getenv("APPLICATION_ADDRESS"),
+			Addr:    conf.Address,
 			Handler: engine,
 		},
+		checks: make(map[string]Check),
 	}
+
+	engine.GET("/", healtcheck)
+	engine.GET("/livez", e.livez)
+	engine.GET("/readyz", e.readyz)
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+	return e
 }
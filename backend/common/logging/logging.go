@@ -1,15 +1,60 @@
 package logging
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oupo1337/velibs/backend/common/config"
 )
 
-func Init(serviceName string) {
-	handler := slog.NewJSONHandler(os.Stdout, nil)
+// traceHandler adds the trace and span IDs of the record's context, so that
+// logs can be linked to their trace.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+func Init(serviceName string, conf config.Logging) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(conf.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", conf.Level, err)
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch conf.Format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, options)
+	default:
+		return fmt.Errorf("invalid log format %q", conf.Format)
+	}
 
-	logger := slog.New(handler.WithAttrs([]slog.Attr{
+	logger := slog.New(traceHandler{handler}.WithAttrs([]slog.Attr{
 		slog.String("service", serviceName),
 	}))
 	slog.SetDefault(logger)
+	return nil
 }
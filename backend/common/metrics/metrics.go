@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "velib"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cron_job_runs_total",
+		Help:      "Number of cron job runs, by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cron_job_duration_seconds",
+		Help:      "Duration of cron job runs, by job.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"job"})
+
+	StationsReporting = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stations_reporting",
+		Help:      "Number of stations in the last accepted status feed.",
+	})
+
+	BikesAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "bikes_available",
+		Help:      "Number of bikes docked in stations in the last accepted status feed, by type.",
+	}, []string{"type"})
+
+	FreeFloatingBikesSeen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "free_floating_bikes_seen",
+		Help:      "Number of free floating bikes in the last accepted feed.",
+	})
+
+	feeds = &feedCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "feed_staleness_seconds"),
+			"Time elapsed since the upstream feed was last updated, by dataset.",
+			[]string{"dataset"}, nil,
+		),
+		updates: make(map[string]time.Time),
+	}
+)
+
+type feedCollector struct {
+	desc    *prometheus.Desc
+	mu      sync.Mutex
+	updates map[string]time.Time
+}
+
+func (f *feedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.desc
+}
+
+func (f *feedCollector) Collect(ch chan<- prometheus.Metric) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for dataset, updated := range f.updates {
+		ch <- prometheus.MustNewConstMetric(f.desc, prometheus.GaugeValue, time.Since(updated).Seconds(), dataset)
+	}
+}
+
+func SetFeedUpdated(dataset string, updated time.Time) {
+	feeds.mu.Lock()
+	defer feeds.mu.Unlock()
+
+	feeds.updates[dataset] = updated
+}
+
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func ObserveJob(job, outcome string, duration time.Duration) {
+	jobRuns.WithLabelValues(job, outcome).Inc()
+	jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+func Register(c ...prometheus.Collector) error {
+	for _, collector := range c {
+		if err := registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func Init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		jobRuns,
+		jobDuration,
+		StationsReporting,
+		BikesAvailable,
+		FreeFloatingBikesSeen,
+		feeds,
+	)
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// compressor is implemented by both gzip and brotli writers.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter compresses the body on the fly, the compressor is only
+// created on the first write so that empty responses stay empty.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding   string
+	compressor compressor
+}
+
+func (w *compressWriter) init() {
+	if w.compressor != nil {
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+
+	switch w.encoding {
+	case encodingBrotli:
+		w.compressor = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+	default:
+		w.compressor, _ = gzip.NewWriterLevel(w.ResponseWriter, gzip.DefaultCompression)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.init()
+	return w.compressor.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written also reports the bytes still held by the compressor, so that nothing
+// is appended to a partially compressed body.
+func (w *compressWriter) Written() bool {
+	return w.compressor != nil || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) close() error {
+	if w.compressor == nil {
+		return nil
+	}
+	return w.compressor.Close()
+}
+
+// AddVary adds name to the Vary header, keeping it on a single line since some
+// caches only read the first one.
+func AddVary(header http.Header, name string) {
+	vary := header.Get("Vary")
+	for _, field := range strings.Split(vary, ",") {
+		if strings.EqualFold(strings.TrimSpace(field), name) {
+			return
+		}
+	}
+
+	if vary != "" {
+		name = vary + ", " + name
+	}
+	header.Set("Vary", name)
+}
+
+// negotiateEncoding picks brotli over gzip among the encodings accepted with
+// a non-zero quality.
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		accepted[strings.ToLower(name)] = quality > 0
+	}
+
+	switch {
+	case accepted[encodingBrotli]:
+		return encodingBrotli
+	case accepted[encodingGzip]:
+		return encodingGzip
+	default:
+		return ""
+	}
+}
+
+func NewCompression(options ...LoggerOption) gin.HandlerFunc {
+	l := &config{}
+	for _, option := range options {
+		option(l)
+	}
+
+	ignore := make(map[string]struct{}, len(l.ignorePath))
+	for _, path := range l.ignorePath {
+		ignore[path] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := ignore[c.Request.URL.Path]; ok {
+			return
+		}
+
+		// Uncompressed responses vary as much as compressed ones, caches must
+		// not serve them to the clients accepting an encoding.
+		AddVary(c.Writer.Header(), "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+		c.Writer = writer
+		defer func() {
+			_ = writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
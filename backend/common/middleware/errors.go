@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is the body of every error response, following RFC 9457.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type errorMapping struct {
+	target error
+	status int
+	code   string
+}
+
+type errorsConfig struct {
+	mappings []errorMapping
+}
+
+type ErrorOption func(*errorsConfig)
+
+// WithErrorStatus renders the errors matching target, as per errors.Is, with
+// the given status and code instead of a 500.
+func WithErrorStatus(target error, status int, code string) ErrorOption {
+	return func(c *errorsConfig) {
+		c.mappings = append(c.mappings, errorMapping{target: target, status: status, code: code})
+	}
+}
+
+func (c *errorsConfig) problem(err *gin.Error) Problem {
+	if err.IsType(gin.ErrorTypeBind) {
+		return Problem{Status: http.StatusBadRequest, Code: "invalid_request", Detail: err.Error()}
+	}
+
+	for _, mapping := range c.mappings {
+		if errors.Is(err.Err, mapping.target) {
+			return Problem{Status: mapping.status, Code: mapping.code, Detail: mapping.target.Error()}
+		}
+	}
+	return Problem{Status: http.StatusInternalServerError, Code: "internal_error"}
+}
+
+// NewErrors renders the last error attached to the context by the handlers as
+// a problem+json response, unless they already wrote one.
+func NewErrors(options ...ErrorOption) gin.HandlerFunc {
+	conf := &errorsConfig{}
+	for _, option := range options {
+		option(conf)
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		err := c.Errors.Last()
+		if err == nil || c.Writer.Written() {
+			return
+		}
+
+		writeProblem(c, conf.problem(err))
+	}
+}
+
+// writeProblem completes problem with the request it answers and writes it in
+// place of the successful response.
+func writeProblem(c *gin.Context, problem Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = RequestID(c)
+
+	// Drop the headers set for the successful response.
+	c.Writer.Header().Del("Cache-Control")
+	c.Writer.Header().Del("Content-Disposition")
+	c.Header("Content-Type", problemContentType)
+	c.JSON(problem.Status, problem)
+}
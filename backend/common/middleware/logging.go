@@ -77,6 +77,9 @@ func NewLogging(options ...LoggerOption) gin.HandlerFunc {
 			slog.Int("data_length", dataLength),
 			slog.String("user_agent", userAgent),
 		}
+		if id := RequestID(c); id != "" {
+			attributes = append(attributes, slog.String("request_id", id))
+		}
 		if c.Errors != nil {
 			attributes = append(attributes, slog.String("error", c.Errors.String()))
 		}
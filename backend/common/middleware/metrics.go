@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/common/metrics"
+)
+
+func NewMetrics(options ...LoggerOption) gin.HandlerFunc {
+	l := &config{}
+	for _, option := range options {
+		option(l)
+	}
+
+	ignore := make(map[string]struct{}, len(l.ignorePath))
+	for _, path := range l.ignorePath {
+		ignore[path] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := ignore[c.Request.URL.Path]; ok {
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewRecovery turns the panics of the handlers into a problem+json 500. When
+// the response was already started, the connection is aborted instead so that
+// the client can't mistake the truncated body for a complete one.
+func NewRecovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		_ = c.Error(fmt.Errorf("panic: %v", recovered))
+		slog.ErrorContext(c.Request.Context(), "panic",
+			slog.Any("error", recovered),
+			slog.String("request_id", RequestID(c)),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		if c.Writer.Written() {
+			panic(http.ErrAbortHandler)
+		}
+		writeProblem(c, Problem{Status: http.StatusInternalServerError, Code: "internal_error"})
+		c.Abort()
+	})
+}
@@ -0,0 +1,425 @@
+package topojson
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/simplify"
+)
+
+const ContentType = "application/json"
+
+// Topology collects GeoJSON features and encodes them as a TopoJSON topology,
+// where the borders shared by several features are only written once.
+//
+// Borders are shared when the features have the exact same vertices along
+// them, which holds for boundary layers cut from the same source.
+type Topology struct {
+	name      string
+	members   map[string]any
+	features  []*geojson.Feature
+	tolerance float64
+}
+
+type Option func(*Topology)
+
+// WithMember adds a foreign member to the topology.
+func WithMember(name string, value any) Option {
+	return func(t *Topology) {
+		t.members[name] = value
+	}
+}
+
+// WithSimplify simplifies the arcs once the features are cut into them, so
+// that neighbours keep sharing the same simplified border. The tolerance is in
+// the unit of the coordinates.
+func WithSimplify(tolerance float64) Option {
+	return func(t *Topology) {
+		t.tolerance = tolerance
+	}
+}
+
+// New returns a topology whose features are gathered in a single
+// GeometryCollection object called name.
+func New(name string, options ...Option) *Topology {
+	topology := &Topology{
+		name:    name,
+		members: make(map[string]any),
+	}
+
+	for _, option := range options {
+		option(topology)
+	}
+	return topology
+}
+
+// WriteFeature adds a feature encoded as GeoJSON to the topology.
+func (t *Topology) WriteFeature(feature []byte) error {
+	f, err := geojson.UnmarshalFeature(feature)
+	if err != nil {
+		return fmt.Errorf("geojson.UnmarshalFeature error: %w", err)
+	}
+	t.features = append(t.features, f)
+	return nil
+}
+
+type object struct {
+	Type        string             `json:"type"`
+	ID          any                `json:"id,omitempty"`
+	Arcs        any                `json:"arcs,omitempty"`
+	Coordinates any                `json:"coordinates,omitempty"`
+	Properties  geojson.Properties `json:"properties,omitempty"`
+}
+
+type collection struct {
+	Type       string   `json:"type"`
+	Geometries []object `json:"geometries"`
+}
+
+// build cuts the features into arcs and returns their geometries referencing
+// them, in the order of the features.
+func (t *Topology) build() (*arcs, []object, error) {
+	arcs := newArcs()
+	for _, feature := range t.features {
+		arcs.join(feature.Geometry)
+	}
+
+	geometries := make([]object, 0, len(t.features))
+	for _, feature := range t.features {
+		geometry, err := arcs.object(feature.Geometry)
+		if err != nil {
+			return nil, nil, err
+		}
+		geometry.ID = feature.ID
+		geometry.Properties = feature.Properties
+		geometries = append(geometries, geometry)
+	}
+
+	if t.tolerance > 0 {
+		arcs.simplify(t.tolerance)
+	}
+	return arcs, geometries, nil
+}
+
+// Features returns the features with their geometries rebuilt from the arcs,
+// which is how simplified features are sent as GeoJSON without opening gaps
+// between them.
+func (t *Topology) Features() ([]*geojson.Feature, error) {
+	arcs, geometries, err := t.build()
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]*geojson.Feature, 0, len(geometries))
+	for _, geometry := range geometries {
+		feature := geojson.NewFeature(arcs.geometry(geometry))
+		feature.ID = geometry.ID
+		feature.Properties = geometry.Properties
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// Encode writes the topology to w.
+func (t *Topology) Encode(w io.Writer) error {
+	arcs, geometries, err := t.build()
+	if err != nil {
+		return err
+	}
+
+	buffer := bufio.NewWriter(w)
+	if _, err := buffer.WriteString(`{"type":"Topology",`); err != nil {
+		return err
+	}
+	for name, value := range t.members {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("json.Marshal %s error: %w", name, err)
+		}
+		if _, err := fmt.Fprintf(buffer, "%q:%s,", name, data); err != nil {
+			return err
+		}
+	}
+
+	objects, err := json.Marshal(map[string]collection{
+		t.name: {Type: "GeometryCollection", Geometries: geometries},
+	})
+	if err != nil {
+		return fmt.Errorf("json.Marshal objects error: %w", err)
+	}
+	lines, err := json.Marshal(arcs.lines)
+	if err != nil {
+		return fmt.Errorf("json.Marshal arcs error: %w", err)
+	}
+	if _, err := fmt.Fprintf(buffer, `"objects":%s,"arcs":%s}`, objects, lines); err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
+
+// neighbours are the points around a vertex the first time it was seen.
+type neighbours struct {
+	previous orb.Point
+	next     orb.Point
+}
+
+type arcs struct {
+	seen      map[orb.Point]neighbours
+	junctions map[orb.Point]bool
+	lines     []orb.LineString
+	index     map[string]int
+}
+
+func newArcs() *arcs {
+	return &arcs{
+		seen:      make(map[orb.Point]neighbours),
+		junctions: make(map[orb.Point]bool),
+		index:     make(map[string]int),
+	}
+}
+
+// join marks as junctions the vertices where the lines and rings of geometry
+// stop following the same path as the ones seen before.
+func (a *arcs) join(geometry orb.Geometry) {
+	switch g := geometry.(type) {
+	case orb.LineString:
+		a.joinLine(g)
+	case orb.MultiLineString:
+		for _, line := range g {
+			a.joinLine(line)
+		}
+	case orb.Polygon:
+		for _, ring := range g {
+			a.joinRing(ring)
+		}
+	case orb.MultiPolygon:
+		for _, polygon := range g {
+			for _, ring := range polygon {
+				a.joinRing(ring)
+			}
+		}
+	}
+}
+
+func (a *arcs) visit(point, previous, next orb.Point) {
+	first, ok := a.seen[point]
+	if !ok {
+		a.seen[point] = neighbours{previous: previous, next: next}
+		return
+	}
+
+	sameWay := first.previous == previous && first.next == next
+	otherWay := first.previous == next && first.next == previous
+	if !sameWay && !otherWay {
+		a.junctions[point] = true
+	}
+}
+
+func (a *arcs) joinLine(line orb.LineString) {
+	if len(line) == 0 {
+		return
+	}
+
+	a.junctions[line[0]] = true
+	a.junctions[line[len(line)-1]] = true
+	for i := 1; i < len(line)-1; i++ {
+		a.visit(line[i], line[i-1], line[i+1])
+	}
+}
+
+func (a *arcs) joinRing(ring orb.Ring) {
+	points := openRing(ring)
+	for i := range points {
+		previous := points[(i+len(points)-1)%len(points)]
+		next := points[(i+1)%len(points)]
+		a.visit(points[i], previous, next)
+	}
+}
+
+// openRing drops the closing point of a ring.
+func openRing(ring orb.Ring) []orb.Point {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// add returns the index of line in the arcs, reusing an existing arc when it
+// is the same or the reverse of line, the latter referenced as its one's
+// complement.
+func (a *arcs) add(line orb.LineString) int {
+	if i, ok := a.index[key(line)]; ok {
+		return i
+	}
+
+	reversed := line.Clone()
+	reversed.Reverse()
+	if i, ok := a.index[key(reversed)]; ok {
+		return ^i
+	}
+
+	a.lines = append(a.lines, line)
+	a.index[key(line)] = len(a.lines) - 1
+	return len(a.lines) - 1
+}
+
+func key(line orb.LineString) string {
+	return fmt.Sprint([]orb.Point(line))
+}
+
+// cut splits points at the junctions, points being a ring when closed is set.
+func (a *arcs) cut(points []orb.Point, closed bool) []int {
+	if !closed {
+		var indexes []int
+		start := 0
+		for i := 1; i < len(points); i++ {
+			if a.junctions[points[i]] || i == len(points)-1 {
+				indexes = append(indexes, a.add(orb.LineString(points[start:i+1]).Clone()))
+				start = i
+			}
+		}
+		return indexes
+	}
+
+	// Rings start on a junction, or on their smallest point when they have
+	// none so that the same ring is always cut the same way.
+	start := -1
+	for i := range points {
+		if a.junctions[points[i]] {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		start = 0
+		for i := range points {
+			if points[i][0] < points[start][0] || (points[i][0] == points[start][0] && points[i][1] < points[start][1]) {
+				start = i
+			}
+		}
+	}
+
+	rotated := make([]orb.Point, 0, len(points)+1)
+	rotated = append(rotated, points[start:]...)
+	rotated = append(rotated, points[:start]...)
+	rotated = append(rotated, points[start])
+	return a.cut(rotated, false)
+}
+
+func (a *arcs) rings(polygon orb.Polygon) [][]int {
+	rings := make([][]int, 0, len(polygon))
+	for _, ring := range polygon {
+		rings = append(rings, a.cut(openRing(ring), true))
+	}
+	return rings
+}
+
+// simplify reduces every arc with Douglas-Peucker. The ends of the arcs are
+// junctions and are kept, the arcs closing on themselves keep enough points to
+// remain a ring.
+func (a *arcs) simplify(tolerance float64) {
+	simplifier := simplify.DouglasPeucker(tolerance)
+	for i, line := range a.lines {
+		simplified := simplifier.LineString(line.Clone())
+		if line[0] == line[len(line)-1] && len(simplified) < 4 {
+			continue
+		}
+		a.lines[i] = simplified
+	}
+}
+
+// stitch joins the arcs referenced by indexes back into a line.
+func (a *arcs) stitch(indexes []int) orb.LineString {
+	var line orb.LineString
+	for _, i := range indexes {
+		var arc orb.LineString
+		if i < 0 {
+			arc = a.lines[^i].Clone()
+			arc.Reverse()
+		} else {
+			arc = a.lines[i]
+		}
+		if len(line) > 0 {
+			arc = arc[1:]
+		}
+		line = append(line, arc...)
+	}
+	return line
+}
+
+// polygon stitches the rings of a polygon, dropping the ones simplified
+// below a triangle and the whole polygon when its exterior is.
+func (a *arcs) polygon(rings [][]int) orb.Polygon {
+	polygon := make(orb.Polygon, 0, len(rings))
+	for i, indexes := range rings {
+		ring := orb.Ring(a.stitch(indexes))
+		if len(ring) < 4 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon
+}
+
+// geometry converts a geometry object back to the geometry it references.
+func (a *arcs) geometry(o object) orb.Geometry {
+	switch arcs := o.Arcs.(type) {
+	case []int:
+		return a.stitch(arcs)
+	case [][]int:
+		if o.Type == "Polygon" {
+			return a.polygon(arcs)
+		}
+		lines := make(orb.MultiLineString, 0, len(arcs))
+		for _, indexes := range arcs {
+			lines = append(lines, a.stitch(indexes))
+		}
+		return lines
+	case [][][]int:
+		polygons := make(orb.MultiPolygon, 0, len(arcs))
+		for _, rings := range arcs {
+			if polygon := a.polygon(rings); polygon != nil {
+				polygons = append(polygons, polygon)
+			}
+		}
+		return polygons
+	default:
+		return o.Coordinates.(orb.Geometry)
+	}
+}
+
+// object converts geometry to a TopoJSON geometry object referencing the
+// arcs.
+func (a *arcs) object(geometry orb.Geometry) (object, error) {
+	switch g := geometry.(type) {
+	case orb.Point:
+		return object{Type: "Point", Coordinates: g}, nil
+	case orb.MultiPoint:
+		return object{Type: "MultiPoint", Coordinates: g}, nil
+	case orb.LineString:
+		return object{Type: "LineString", Arcs: a.cut(g, false)}, nil
+	case orb.MultiLineString:
+		lines := make([][]int, 0, len(g))
+		for _, line := range g {
+			lines = append(lines, a.cut(line, false))
+		}
+		return object{Type: "MultiLineString", Arcs: lines}, nil
+	case orb.Polygon:
+		return object{Type: "Polygon", Arcs: a.rings(g)}, nil
+	case orb.MultiPolygon:
+		polygons := make([][][]int, 0, len(g))
+		for _, polygon := range g {
+			polygons = append(polygons, a.rings(polygon))
+		}
+		return object{Type: "MultiPolygon", Arcs: polygons}, nil
+	default:
+		return object{}, fmt.Errorf("unsupported geometry %T", geometry)
+	}
+}
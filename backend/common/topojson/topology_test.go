@@ -0,0 +1,175 @@
+package topojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+type encoded struct {
+	Objects map[string]struct {
+		Geometries []struct {
+			Type string          `json:"type"`
+			Arcs json.RawMessage `json:"arcs"`
+		} `json:"geometries"`
+	} `json:"objects"`
+	Arcs [][][2]float64 `json:"arcs"`
+}
+
+func encode(t *testing.T, topology *Topology, geometries ...orb.Geometry) encoded {
+	t.Helper()
+
+	for _, geometry := range geometries {
+		data, err := geojson.NewFeature(geometry).MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON error: %v", err)
+		}
+		if err := topology.WriteFeature(data); err != nil {
+			t.Fatalf("WriteFeature error: %v", err)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := topology.Encode(&buffer); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	var result encoded
+	if err := json.Unmarshal(buffer.Bytes(), &result); err != nil {
+		t.Fatalf("json.Unmarshal error: %v", err)
+	}
+	return result
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name       string
+		geometries []orb.Geometry
+		arcs       [][][2]float64
+		references []string
+	}{
+		{
+			name: "shared border",
+			geometries: []orb.Geometry{
+				orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+				orb.Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}},
+			},
+			arcs: [][][2]float64{
+				{{1, 0}, {1, 1}},
+				{{1, 1}, {0, 1}, {0, 0}, {1, 0}},
+				{{1, 0}, {2, 0}, {2, 1}, {1, 1}},
+			},
+			references: []string{`[[0,1]]`, `[[2,-1]]`},
+		},
+		{
+			name: "reversed arc",
+			geometries: []orb.Geometry{
+				orb.LineString{{0, 0}, {1, 1}, {2, 0}},
+				orb.LineString{{2, 0}, {1, 1}, {0, 0}},
+			},
+			arcs: [][][2]float64{
+				{{0, 0}, {1, 1}, {2, 0}},
+			},
+			references: []string{`[0]`, `[-1]`},
+		},
+		{
+			name: "ring without junction",
+			geometries: []orb.Geometry{
+				orb.Polygon{{{1, 1}, {0, 1}, {0, 0}, {1, 0}, {1, 1}}},
+			},
+			arcs: [][][2]float64{
+				{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}},
+			},
+			references: []string{`[[0]]`},
+		},
+		{
+			name: "hole",
+			geometries: []orb.Geometry{
+				orb.Polygon{
+					{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+					{{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}},
+				},
+			},
+			arcs: [][][2]float64{
+				{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+				{{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}},
+			},
+			references: []string{`[[0],[1]]`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := encode(t, New("areas"), test.geometries...)
+
+			if !reflect.DeepEqual(result.Arcs, test.arcs) {
+				t.Errorf("arcs = %v, want %v", result.Arcs, test.arcs)
+			}
+
+			geometries := result.Objects["areas"].Geometries
+			if len(geometries) != len(test.references) {
+				t.Fatalf("got %d geometries, want %d", len(geometries), len(test.references))
+			}
+			for i, geometry := range geometries {
+				if string(geometry.Arcs) != test.references[i] {
+					t.Errorf("geometry %d arcs = %s, want %s", i, geometry.Arcs, test.references[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSimplifyKeepsSharedBorders(t *testing.T) {
+	topology := New("areas", WithSimplify(0.01))
+	result := encode(t, topology,
+		orb.Polygon{{{0, 0}, {1, 0}, {1.001, 0.5}, {1, 1}, {0, 1}, {0, 0}}},
+		orb.Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1.001, 0.5}, {1, 0}}},
+	)
+
+	want := [][][2]float64{{{1, 0}, {1, 1}}}
+	if !reflect.DeepEqual(result.Arcs[:1], want) {
+		t.Errorf("shared arc = %v, want %v", result.Arcs[:1], want)
+	}
+
+	features, err := topology.Features()
+	if err != nil {
+		t.Fatalf("Features error: %v", err)
+	}
+	left := features[0].Geometry.(orb.Polygon)[0]
+	right := features[1].Geometry.(orb.Polygon)[0]
+	for _, point := range []orb.Point{{1, 0}, {1, 1}} {
+		if !contains(left, point) || !contains(right, point) {
+			t.Errorf("%v isn't shared by both rings: %v and %v", point, left, right)
+		}
+	}
+	if contains(left, orb.Point{1.001, 0.5}) || contains(right, orb.Point{1.001, 0.5}) {
+		t.Errorf("the shared border wasn't simplified: %v and %v", left, right)
+	}
+}
+
+func TestSimplifyKeepsSmallRings(t *testing.T) {
+	topology := New("areas", WithSimplify(10))
+	square := orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}
+	encode(t, topology, square)
+
+	features, err := topology.Features()
+	if err != nil {
+		t.Fatalf("Features error: %v", err)
+	}
+	if ring := features[0].Geometry.(orb.Polygon)[0]; len(ring) != 5 {
+		t.Errorf("ring = %v, want the square kept", ring)
+	}
+}
+
+func contains(ring orb.Ring, point orb.Point) bool {
+	for _, p := range ring {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
@@ -2,20 +2,24 @@ package tracing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"strconv"
+	"net/url"
 	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oupo1337/velibs/backend/common/config"
 )
 
 const ScopeName = "github.com/oupo1337/velibs/backend/tracing"
@@ -26,11 +30,58 @@ func Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption)
 	return tracer.Start(ctx, spanName, opts...)
 }
 
-func Init(serviceName string) error {
-	enabled, _ := strconv.ParseBool(os.Getenv("TELEMETRY_ENABLED"))
-	if !enabled {
+func newTracerProvider(ctx context.Context, conf config.Telemetry, res *resource.Resource) (*sdktrace.TracerProvider, error) {
+	var options []otlptracehttp.Option
+	if conf.Endpoint != "" {
+		endpoint, err := url.JoinPath(conf.Endpoint, "v1/traces")
+		if err != nil {
+			return nil, fmt.Errorf("url.JoinPath error: %w", err)
+		}
+		options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+	}
+
+	exporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(options...))
+	if err != nil {
+		return nil, fmt.Errorf("otlptrace.New error: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(1000*time.Millisecond)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SamplingRatio))),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+func newMeterProvider(ctx context.Context, conf config.Telemetry, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	var options []otlpmetrichttp.Option
+	switch {
+	case conf.MetricsEndpoint != "":
+		options = append(options, otlpmetrichttp.WithEndpointURL(conf.MetricsEndpoint))
+	case conf.Endpoint != "":
+		endpoint, err := url.JoinPath(conf.Endpoint, "v1/metrics")
+		if err != nil {
+			return nil, fmt.Errorf("url.JoinPath error: %w", err)
+		}
+		options = append(options, otlpmetrichttp.WithEndpointURL(endpoint))
+	}
+
+	exporter, err := otlpmetrichttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("otlpmetrichttp.New error: %w", err)
+	}
+
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(conf.MetricInterval))),
+		sdkmetric.WithResource(res),
+	), nil
+}
+
+// Init installs the global tracer and meter providers and returns their
+// shutdown function, which flushes the spans and metrics still batched.
+func Init(serviceName string, conf config.Telemetry) (func(ctx context.Context) error, error) {
+	if !conf.Enabled {
 		slog.Warn("telemetry is disabled")
-		return nil
+		return func(context.Context) error { return nil }, nil
 	}
 
 	res, err := resource.New(
@@ -45,7 +96,7 @@ func Init(serviceName string) error {
 		resource.WithOSType(),
 	)
 	if err != nil {
-		return fmt.Errorf("resource.New error: %w", err)
+		return nil, fmt.Errorf("resource.New error: %w", err)
 	}
 
 	otel.SetTextMapPropagator(
@@ -54,15 +105,19 @@ func Init(serviceName string) error {
 			propagation.Baggage{},
 		))
 
-	exporter, err := otlptrace.New(context.Background(), otlptracehttp.NewClient())
+	tp, err := newTracerProvider(context.Background(), conf, res)
 	if err != nil {
-		return fmt.Errorf("otlptrace.New error: %w", err)
+		return nil, fmt.Errorf("newTracerProvider error: %w", err)
 	}
-
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(1000*time.Millisecond)),
-		sdktrace.WithResource(res),
-	)
 	otel.SetTracerProvider(tp)
-	return nil
+
+	mp, err := newMeterProvider(context.Background(), conf, res)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("newMeterProvider error: %w", err), tp.Shutdown(context.Background()))
+	}
+	otel.SetMeterProvider(mp)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
 }
@@ -0,0 +1,67 @@
+package validation
+
+type Rule[T any] struct {
+	Name  string
+	Check func(record T) error
+}
+
+type Rejection[T any] struct {
+	Record T
+	Rule   string
+	Reason string
+}
+
+type Report[T any] struct {
+	Valid    []T
+	Rejected []Rejection[T]
+}
+
+func (r Report[T]) Received() int {
+	return len(r.Valid) + len(r.Rejected)
+}
+
+func (r Report[T]) RejectedByRule() map[string]int {
+	counts := make(map[string]int)
+	for _, rejection := range r.Rejected {
+		counts[rejection.Rule]++
+	}
+	return counts
+}
+
+type Validator[T any] struct {
+	rules []Rule[T]
+}
+
+// Validate splits records between the valid ones and those failing a rule,
+// a record is rejected by the first rule it fails.
+func (v *Validator[T]) Validate(records []T) Report[T] {
+	report := Report[T]{
+		Valid: make([]T, 0, len(records)),
+	}
+
+	for _, record := range records {
+		rejected := false
+		for _, rule := range v.rules {
+			if err := rule.Check(record); err != nil {
+				report.Rejected = append(report.Rejected, Rejection[T]{
+					Record: record,
+					Rule:   rule.Name,
+					Reason: err.Error(),
+				})
+				rejected = true
+				break
+			}
+		}
+
+		if !rejected {
+			report.Valid = append(report.Valid, record)
+		}
+	}
+	return report
+}
+
+func New[T any](rules ...Rule[T]) *Validator[T] {
+	return &Validator[T]{
+		rules: rules,
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AlertMetric is the value of a station an alert watches.
+type AlertMetric string
+
+const (
+	AlertMechanical AlertMetric = "mechanical"
+	AlertElectric   AlertMetric = "electric"
+	AlertDocks      AlertMetric = "docks"
+)
+
+// AlertOperator compares the metric of a station to the threshold of an alert.
+type AlertOperator string
+
+const (
+	AlertAtLeast AlertOperator = "gte"
+	AlertAtMost  AlertOperator = "lte"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Alert triggers a webhook when the metric of one of its stations has matched
+// the condition for Duration, once per matching period and at most once per
+// Cooldown.
+type Alert struct {
+	ID         uuid.UUID
+	Owner      string
+	StationIDs []int64
+	Metric     AlertMetric
+	Operator   AlertOperator
+	Threshold  int
+	Duration   time.Duration
+	Cooldown   time.Duration
+	WebhookURL string
+	Secret     string
+	CreatedAt  time.Time
+}
+
+// Value returns the metric of the alert in status.
+func (a Alert) Value(status StationStatus) int {
+	switch a.Metric {
+	case AlertMechanical:
+		return status.Mechanical()
+	case AlertElectric:
+		return status.Electric()
+	default:
+		return status.NumDocksAvailable
+	}
+}
+
+func (a Alert) Matches(value int) bool {
+	if a.Operator == AlertAtMost {
+		return value <= a.Threshold
+	}
+	return value >= a.Threshold
+}
+
+// AlertState is where a station stands regarding an alert.
+type AlertState struct {
+	AlertID       uuid.UUID
+	StationID     int64
+	MatchingSince *time.Time
+	LastTriggered *time.Time
+}
+
+// AlertPayload is the body of the webhooks.
+type AlertPayload struct {
+	AlertID   uuid.UUID     `json:"alert_id"`
+	StationID int64         `json:"station_id"`
+	Metric    AlertMetric   `json:"metric"`
+	Operator  AlertOperator `json:"operator"`
+	Threshold int           `json:"threshold"`
+	Value     int           `json:"value"`
+	Since     time.Time     `json:"since"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+type AlertDelivery struct {
+	ID            int64
+	AlertID       uuid.UUID
+	StationID     int64
+	Timestamp     time.Time
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	StatusCode    *int
+	Error         *string
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+	WebhookURL    string
+	Secret        string
+}
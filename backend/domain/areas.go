@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+const (
+	AreaBoroughs  = "boroughs"
+	AreaDistricts = "districts"
+
+	// OutsideParis gathers the stations outside of the boroughs, those whose
+	// commune isn't known.
+	OutsideParis = "Hors Paris"
+)
+
+// AreaStatuses are the bikes docked in the stations of an area at a slot.
+type AreaStatuses struct {
+	Name       string  `json:"name"`
+	Code       string  `json:"code,omitempty"`
+	IDs        []int64 `json:"ids"`
+	Mechanical int     `json:"mechanical"`
+	Electric   int     `json:"electric"`
+}
+
+// RankingMetric is what areas are ranked by.
+type RankingMetric string
+
+const (
+	RankByBikesPerStation RankingMetric = "bikes_per_station"
+	RankByEmptyShare      RankingMetric = "empty_share"
+	RankByElectricShare   RankingMetric = "electric_share"
+)
+
+// Ranking is where an area stands among the others over a period, its
+// metrics are averaged over the slots of the period. The stations outside of
+// Paris are ranked by commune.
+type Ranking struct {
+	Rank            int     `json:"rank"`
+	Name            string  `json:"name"`
+	Code            string  `json:"code,omitempty"`
+	OutsideParis    bool    `json:"outside_paris"`
+	Stations        int     `json:"stations"`
+	BikesPerStation float64 `json:"bikes_per_station"`
+	EmptyShare      float64 `json:"empty_share"`
+	ElectricShare   float64 `json:"electric_share"`
+}
+
+type Rankings struct {
+	Area     string        `json:"area"`
+	Metric   RankingMetric `json:"metric"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Rankings []Ranking     `json:"rankings"`
+}
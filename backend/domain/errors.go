@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrTimeout          = errors.New("timeout")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrLimitReached     = errors.New("limit reached")
+)
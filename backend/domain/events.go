@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Snapshot tells that a new slot of a dataset was ingested.
+type Snapshot struct {
+	Dataset   string    `json:"dataset"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StationDelta is the new number of bikes of a station whose count changed
+// since the previous slot, and by how much.
+type StationDelta struct {
+	StationID        int64 `json:"station_id"`
+	Mechanical       int   `json:"mechanical"`
+	Electric         int   `json:"electric"`
+	MechanicalChange int   `json:"mechanical_change"`
+	ElectricChange   int   `json:"electric_change"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type StatusesFilter struct {
+	From       time.Time
+	To         time.Time
+	StationIDs []int
+	Borough    string
+}
+
+type StatusRecord struct {
+	Timestamp  time.Time
+	StationID  int64
+	Name       string
+	Capacity   int
+	Latitude   float64
+	Longitude  float64
+	Mechanical int
+	Electric   int
+}
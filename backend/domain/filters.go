@@ -0,0 +1,42 @@
+package domain
+
+// BoundingBox is an area given by its south-west and north-east corners, in
+// WGS 84 coordinates.
+type BoundingBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+func (b BoundingBox) Valid() bool {
+	return -180 <= b.MinLon && b.MinLon <= b.MaxLon && b.MaxLon <= 180 &&
+		-90 <= b.MinLat && b.MinLat <= b.MaxLat && b.MaxLat <= 90
+}
+
+// BoundariesFilter selects the boroughs, districts or communes to send and the
+// precision of their coordinates.
+type BoundariesFilter struct {
+	BBox *BoundingBox
+	// Precision is the number of decimal digits of the coordinates.
+	Precision int
+}
+
+type StationsFilter struct {
+	BBox        *BoundingBox
+	MinElectric *int
+}
+
+type BikeLanesFilter struct {
+	BBox           *BoundingBox
+	Amenagement    string
+	Arrondissement string
+	Bidirectional  *bool
+}
+
+type FreeFloatingBikesFilter struct {
+	BBox        *BoundingBox
+	IsDisabled  *bool
+	MinRange    *int
+	VehicleType string
+}
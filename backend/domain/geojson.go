@@ -7,6 +7,7 @@ import (
 type BikeLanesGeoJSON GeoJSON[BikeLanesProperties]
 type DistrictsGeoJSON GeoJSON[DistrictsProperties]
 type BoroughsGeoJSON GeoJSON[BoroughsProperties]
+type CommunesGeoJSON GeoJSON[CommunesProperties]
 
 type BoroughsProperties struct {
 	NSqAr     int     `json:"n_sq_ar"`
@@ -40,6 +41,11 @@ type DistrictsProperties struct {
 	StPerimeterShape float64 `json:"st_perimeter_shape"`
 }
 
+type CommunesProperties struct {
+	Code string `json:"code"`
+	Nom  string `json:"nom"`
+}
+
 type BikeLanesProperties struct {
 	OsmId                     int    `json:"osm_id"`
 	Nom                       string `json:"nom"`
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type FetchQuality struct {
+	Dataset   string
+	Timestamp time.Time
+	Received  int
+	Accepted  int
+	Rejected  int
+}
+
+type QuarantinedRecord struct {
+	Rule    string
+	Reason  string
+	Payload any
+}
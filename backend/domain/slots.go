@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+const SlotDuration = 10 * time.Minute
+
+// TimeZone is where the stations are, time of day profiles follow its clock.
+const TimeZone = "Europe/Paris"
+
+const (
+	DatasetStations          = "stations"
+	DatasetStatuses          = "statuses"
+	DatasetFreeFloatingBikes = "free_floating_bikes"
+)
+
+func Slot(t time.Time) time.Time {
+	return t.Truncate(SlotDuration)
+}
+
+// Snap tells which slot to use when a requested timestamp has no data.
+type Snap string
+
+const (
+	SnapBefore  Snap = "before"
+	SnapAfter   Snap = "after"
+	SnapNearest Snap = "nearest"
+)
@@ -14,3 +14,23 @@ type StationStatus struct {
 	IsRenting         int `json:"is_renting"`
 	LastReported      int `json:"last_reported"`
 }
+
+func (s StationStatus) Mechanical() int {
+	mechanical := 0
+	for _, available := range s.NumBikesAvailableTypes {
+		if available.Mechanical != nil {
+			mechanical = *available.Mechanical
+		}
+	}
+	return mechanical
+}
+
+func (s StationStatus) Electric() int {
+	electric := 0
+	for _, available := range s.NumBikesAvailableTypes {
+		if available.Ebike != nil {
+			electric = *available.Ebike
+		}
+	}
+	return electric
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Gap is a range of slots missed by the fetcher, bounds included.
+type Gap struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type Timeline struct {
+	Dataset string      `json:"dataset"`
+	Min     time.Time   `json:"min"`
+	Max     time.Time   `json:"max"`
+	From    time.Time   `json:"from"`
+	To      time.Time   `json:"to"`
+	Slots   []time.Time `json:"slots"`
+	Gaps    []Gap       `json:"gaps"`
+}
@@ -4,6 +4,6 @@ import "time"
 
 type Timeseries struct {
 	Date       time.Time `json:"date"`
-	Mechanical int64     `json:"mechanical"`
-	Electric   int64     `json:"electric"`
+	Mechanical *int64    `json:"mechanical"`
+	Electric   *int64    `json:"electric"`
 }
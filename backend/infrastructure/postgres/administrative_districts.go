@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
@@ -23,34 +24,25 @@ func (db *Database) HasAdministrativeDistricts(ctx context.Context) (bool, error
 	return count != 0, nil
 }
 
-func (db *Database) GetAdministrativeDistricts(ctx context.Context, timestamp string) ([]byte, error) {
-	if timestamp == "" {
-		tmstp, err := db.maxVelibTimestamp(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("db.maxVelibTimestamp error: %w", err)
-		}
-		timestamp = tmstp
-	}
+func (db *Database) GetAdministrativeDistricts(ctx context.Context, timestamp time.Time, filter domain.BoundariesFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.add("timestamp = %s", timestamp)
+	conditions.intersects("administrative_districts.shape", filter.BBox)
+	precision := conditions.bind(filter.Precision)
 
-	query := `
-		SELECT JSON_BUILD_OBJECT(
-			'type', 'FeatureCollection',
-			'features', JSON_AGG(ST_AsGeoJSON(t.*)::json)
-		)
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*, 'shape', %s)
 		FROM (
-			SELECT administrative_districts.name, JSON_AGG(id), shape, SUM(statuses.mechanical), SUM(statuses.electric)
+			SELECT administrative_districts.name, JSON_AGG(statuses.station_id), shape, SUM(statuses.mechanical), SUM(statuses.electric)
 			FROM administrative_districts
-			LEFT JOIN stations ON ST_Contains(administrative_districts.shape, stations.position)
-			JOIN statuses ON (stations.id = statuses.station_id AND timestamp = $1)
+			JOIN station_areas ON (station_areas.district = administrative_districts.name)
+			JOIN statuses ON (station_areas.station_id = statuses.station_id)
+			WHERE %s
 			GROUP BY administrative_districts.name, shape
 		) as t(name, ids, shape, mechanical, electric)
-	`
+	`, precision, conditions.String())
 
-	var data []byte
-	if err := db.conn.QueryRow(ctx, query, timestamp).Scan(&data); err != nil {
-		return nil, fmt.Errorf("db.conn.QueryRow error: %w", err)
-	}
-	return data, nil
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
 }
 
 func (db *Database) InsertAdministrativeDistricts(ctx context.Context, districts domain.DistrictsGeoJSON) error {
@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// deliveryLease keeps a claimed delivery from being attempted again while it
+// is in flight.
+const deliveryLease = 5 * time.Minute
+
+// InsertAlert saves alert unless its owner already has maxActive alerts, the
+// owner is locked for the transaction so that concurrent creations can't go
+// over.
+func (db *Database) InsertAlert(ctx context.Context, alert domain.Alert, maxActive int) error {
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext('alerts:' || $1))`
+	countQuery := `SELECT COUNT(*) FROM alerts WHERE owner = $1`
+	insertQuery := `
+		INSERT INTO alerts (id, owner, station_ids, metric, operator, threshold, duration, cooldown, webhook_url, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, make_interval(secs => $7), make_interval(secs => $8), $9, $10, $11)
+	`
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Begin error: %w", classify(err))
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, lockQuery, alert.Owner); err != nil {
+		return fmt.Errorf("tx.Exec error: %w", classify(err))
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, countQuery, alert.Owner).Scan(&count); err != nil {
+		return fmt.Errorf("tx.QueryRow error: %w", classify(err))
+	}
+	if count >= maxActive {
+		return fmt.Errorf("%w: at most %d alerts", domain.ErrLimitReached, maxActive)
+	}
+
+	_, err = tx.Exec(ctx, insertQuery,
+		alert.ID,
+		alert.Owner,
+		alert.StationIDs,
+		alert.Metric,
+		alert.Operator,
+		alert.Threshold,
+		alert.Duration.Seconds(),
+		alert.Cooldown.Seconds(),
+		alert.WebhookURL,
+		alert.Secret,
+		alert.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.Exec error: %w", classify(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit error: %w", classify(err))
+	}
+	return nil
+}
+
+const alertColumns = `
+	id,
+	owner,
+	station_ids,
+	metric,
+	operator,
+	threshold,
+	EXTRACT(EPOCH FROM duration)::BIGINT,
+	EXTRACT(EPOCH FROM cooldown)::BIGINT,
+	webhook_url,
+	secret,
+	created_at
+`
+
+func scanAlert(row pgx.CollectableRow) (domain.Alert, error) {
+	var alert domain.Alert
+	var duration, cooldown int64
+	err := row.Scan(
+		&alert.ID,
+		&alert.Owner,
+		&alert.StationIDs,
+		&alert.Metric,
+		&alert.Operator,
+		&alert.Threshold,
+		&duration,
+		&cooldown,
+		&alert.WebhookURL,
+		&alert.Secret,
+		&alert.CreatedAt,
+	)
+	if err != nil {
+		return domain.Alert{}, fmt.Errorf("rows.Scan error: %w", err)
+	}
+	alert.Duration = time.Duration(duration) * time.Second
+	alert.Cooldown = time.Duration(cooldown) * time.Second
+	return alert, nil
+}
+
+// GetAlert returns the alert id of owner.
+func (db *Database) GetAlert(ctx context.Context, owner string, id uuid.UUID) (domain.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE id = $1 AND owner = $2`
+
+	rows, err := db.conn.Query(ctx, query, id, owner)
+	if err != nil {
+		return domain.Alert{}, fmt.Errorf("db.conn.Query error: %w", classify(err))
+	}
+
+	alert, err := pgx.CollectExactlyOneRow(rows, scanAlert)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Alert{}, fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.Alert{}, fmt.Errorf("pgx.CollectExactlyOneRow error: %w", classify(err))
+	}
+	return alert, nil
+}
+
+func (db *Database) GetAlerts(ctx context.Context) ([]domain.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts`
+
+	rows, err := db.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("db.conn.Query error: %w", err)
+	}
+	return pgx.CollectRows(rows, scanAlert)
+}
+
+func (db *Database) DeleteAlert(ctx context.Context, owner string, id uuid.UUID) error {
+	tag, err := db.conn.Exec(ctx, `DELETE FROM alerts WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return fmt.Errorf("db.conn.Exec error: %w", classify(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("alert %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (db *Database) GetAlertStates(ctx context.Context) ([]domain.AlertState, error) {
+	query := `
+		SELECT alert_id, station_id, matching_since, last_triggered
+		FROM alert_states
+	`
+
+	rows, err := db.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("db.conn.Query error: %w", err)
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AlertState, error) {
+		var state domain.AlertState
+		if err := row.Scan(&state.AlertID, &state.StationID, &state.MatchingSince, &state.LastTriggered); err != nil {
+			return domain.AlertState{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return state, nil
+	})
+}
+
+// UpdateAlerts saves the states of the alerts evaluated on a slot along with
+// the deliveries they triggered.
+func (db *Database) UpdateAlerts(ctx context.Context, states []domain.AlertState, deliveries []domain.AlertDelivery) error {
+	stateQuery := `
+		INSERT INTO alert_states (alert_id, station_id, matching_since, last_triggered)
+		SELECT $1, $2, $3, $4
+		WHERE EXISTS (SELECT 1 FROM alerts WHERE id = $1)
+		ON CONFLICT (alert_id, station_id) DO UPDATE
+		SET matching_since = EXCLUDED.matching_since, last_triggered = EXCLUDED.last_triggered
+	`
+
+	deliveryQuery := `
+		INSERT INTO alert_deliveries (alert_id, station_id, timestamp, payload)
+		SELECT $1, $2, $3, $4
+		WHERE EXISTS (SELECT 1 FROM alerts WHERE id = $1)
+	`
+
+	batch := &pgx.Batch{}
+	for _, state := range states {
+		_ = batch.Queue(stateQuery, state.AlertID, state.StationID, state.MatchingSince, state.LastTriggered)
+	}
+	for _, delivery := range deliveries {
+		_ = batch.Queue(deliveryQuery, delivery.AlertID, delivery.StationID, delivery.Timestamp, delivery.Payload)
+	}
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Begin error: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("tx.SendBatch error: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit error: %w", err)
+	}
+	return nil
+}
+
+// ClaimAlertDeliveries returns up to limit pending deliveries due for an
+// attempt, and postpones them so that no one else attempts them meanwhile.
+func (db *Database) ClaimAlertDeliveries(ctx context.Context, limit int) ([]domain.AlertDelivery, error) {
+	query := `
+		UPDATE alert_deliveries
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM alerts
+		WHERE alerts.id = alert_deliveries.alert_id
+			AND alert_deliveries.id IN (
+				SELECT id
+				FROM alert_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING alert_deliveries.id, alert_id, station_id, timestamp, payload, attempts, webhook_url, secret
+	`
+
+	rows, err := db.conn.Query(ctx, query, limit, deliveryLease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("db.conn.Query error: %w", err)
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AlertDelivery, error) {
+		var delivery domain.AlertDelivery
+		err := row.Scan(
+			&delivery.ID,
+			&delivery.AlertID,
+			&delivery.StationID,
+			&delivery.Timestamp,
+			&delivery.Payload,
+			&delivery.Attempts,
+			&delivery.WebhookURL,
+			&delivery.Secret,
+		)
+		if err != nil {
+			return domain.AlertDelivery{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return delivery, nil
+	})
+}
+
+// RecordAlertDelivery logs the outcome of an attempt of delivery.
+func (db *Database) RecordAlertDelivery(ctx context.Context, delivery domain.AlertDelivery) error {
+	query := `
+		UPDATE alert_deliveries
+		SET
+			status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			status_code = $5,
+			error = $6,
+			delivered_at = $7
+		WHERE id = $1
+	`
+
+	_, err := db.conn.Exec(ctx, query,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("db.conn.Exec error: %w", err)
+	}
+	return nil
+}
+
+// GetAlertDeliveries returns the latest deliveries of an alert, newest first.
+func (db *Database) GetAlertDeliveries(ctx context.Context, alertID uuid.UUID, limit int) ([]domain.AlertDelivery, error) {
+	query := `
+		SELECT id, alert_id, station_id, timestamp, payload, status, attempts, next_attempt_at, status_code, error, created_at, delivered_at
+		FROM alert_deliveries
+		WHERE alert_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := db.conn.Query(ctx, query, alertID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db.conn.Query error: %w", classify(err))
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AlertDelivery, error) {
+		var delivery domain.AlertDelivery
+		err := row.Scan(
+			&delivery.ID,
+			&delivery.AlertID,
+			&delivery.StationID,
+			&delivery.Timestamp,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return domain.AlertDelivery{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return delivery, nil
+	})
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+type area struct {
+	table  string
+	column string
+	// match finds the area named $1, case insensitively.
+	match string
+}
+
+// areas are the tables of the areas stations are mapped to in station_areas,
+// by area.
+var areas = map[string]area{
+	domain.AreaBoroughs: {
+		table:  "boroughs",
+		column: "borough",
+		match:  "LOWER(boroughs.name) = LOWER($1) OR LOWER(boroughs.label) = LOWER($1)",
+	},
+	domain.AreaDistricts: {
+		table:  "administrative_districts",
+		column: "district",
+		match:  "LOWER(administrative_districts.name) = LOWER($1)",
+	},
+}
+
+var rankingOrders = map[domain.RankingMetric]string{
+	domain.RankByBikesPerStation: "bikes_per_station",
+	domain.RankByEmptyShare:      "empty_share",
+	domain.RankByElectricShare:   "electric_share",
+}
+
+func lookupArea(name string) (area, error) {
+	a, ok := areas[name]
+	if !ok {
+		return area{}, fmt.Errorf("%w: unknown area %q", domain.ErrNotFound, name)
+	}
+	return a, nil
+}
+
+// RefreshStationAreas maps every station to the borough, the district and the
+// commune containing it. Only the stations whose areas changed are written.
+func (db *Database) RefreshStationAreas(ctx context.Context) error {
+	query := `
+		INSERT INTO station_areas (station_id, borough, district, commune)
+		SELECT
+			stations.id,
+			(SELECT name FROM boroughs WHERE ST_Contains(boroughs.shape, stations.position) LIMIT 1),
+			(SELECT name FROM administrative_districts WHERE ST_Contains(administrative_districts.shape, stations.position) LIMIT 1),
+			(SELECT code FROM communes WHERE ST_Contains(communes.shape, stations.position) LIMIT 1)
+		FROM stations
+		ON CONFLICT (station_id) DO UPDATE
+		SET borough = EXCLUDED.borough, district = EXCLUDED.district, commune = EXCLUDED.commune
+		WHERE (station_areas.borough, station_areas.district, station_areas.commune)
+			IS DISTINCT FROM (EXCLUDED.borough, EXCLUDED.district, EXCLUDED.commune)
+	`
+
+	if _, err := db.conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("db.conn.Exec error: %w", err)
+	}
+	return nil
+}
+
+// GetAreaStations returns the stations of the area of kind areaName called
+// name. The stations outside of Paris are found by the name or the code of
+// their commune, or all together as domain.OutsideParis.
+func (db *Database) GetAreaStations(ctx context.Context, areaName, name string) ([]int, error) {
+	a, err := lookupArea(areaName)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(ARRAY_AGG(station_areas.station_id) FILTER (WHERE station_areas.station_id IS NOT NULL), '{}')
+		FROM %[1]s
+		LEFT JOIN station_areas ON (station_areas.%[2]s = %[1]s.name)
+		WHERE %[3]s
+		GROUP BY %[1]s.name
+	`, a.table, a.column, a.match)
+
+	var IDs []int
+	err = db.read.QueryRow(ctx, query, name).Scan(&IDs)
+	if err == nil {
+		return IDs, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("db.read.QueryRow error: %w", classify(err))
+	}
+
+	outsideQuery := fmt.Sprintf(`
+		SELECT ARRAY_AGG(station_areas.station_id)
+		FROM station_areas
+		LEFT JOIN communes ON (communes.code = station_areas.commune)
+		WHERE station_areas.%s IS NULL
+			AND (LOWER($1) = LOWER($2) OR LOWER(communes.name) = LOWER($1) OR communes.code = $1)
+	`, a.column)
+
+	if err := db.read.QueryRow(ctx, outsideQuery, name, domain.OutsideParis).Scan(&IDs); err != nil {
+		return nil, fmt.Errorf("db.read.QueryRow error: %w", classify(err))
+	}
+	if IDs == nil {
+		return nil, fmt.Errorf("%w: no %s called %q", domain.ErrNotFound, areaName, name)
+	}
+	return IDs, nil
+}
+
+// GetOutsideStatuses returns the bikes docked at timestamp in the stations
+// outside of the areas of kind areaName, by commune, restricted to bbox when
+// given.
+func (db *Database) GetOutsideStatuses(ctx context.Context, areaName string, timestamp time.Time, bbox *domain.BoundingBox) ([]domain.AreaStatuses, error) {
+	a, err := lookupArea(areaName)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions where
+	outsideParis := conditions.bind(domain.OutsideParis)
+	conditions.add("statuses.timestamp = %s", timestamp)
+	conditions.add("station_areas." + a.column + " IS NULL")
+	conditions.intersects("stations.position", bbox)
+
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(communes.name, %s),
+			COALESCE(communes.code, ''),
+			ARRAY_AGG(statuses.station_id ORDER BY statuses.station_id),
+			SUM(statuses.mechanical),
+			SUM(statuses.electric)
+		FROM station_areas
+		JOIN statuses ON (statuses.station_id = station_areas.station_id)
+		JOIN stations ON (stations.id = station_areas.station_id)
+		LEFT JOIN communes ON (communes.code = station_areas.commune)
+		WHERE %s
+		GROUP BY communes.code, communes.name
+		ORDER BY 1, 2
+	`, outsideParis, conditions.String())
+
+	rows, err := db.read.Query(ctx, query, conditions.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.read.Query error: %w", classify(err))
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AreaStatuses, error) {
+		var statuses domain.AreaStatuses
+		if err := row.Scan(&statuses.Name, &statuses.Code, &statuses.IDs, &statuses.Mechanical, &statuses.Electric); err != nil {
+			return domain.AreaStatuses{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return statuses, nil
+	})
+}
+
+// GetRankings ranks the areas of kind areaName by metric over the slots
+// between from and to included.
+func (db *Database) GetRankings(ctx context.Context, areaName string, metric domain.RankingMetric, ascending bool, from, to time.Time) ([]domain.Ranking, error) {
+	a, err := lookupArea(areaName)
+	if err != nil {
+		return nil, err
+	}
+
+	order, ok := rankingOrders[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking metric %q", metric)
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(station_areas.%[1]s, communes.name, $3),
+			COALESCE(communes.code, ''),
+			station_areas.%[1]s IS NULL,
+			COUNT(DISTINCT statuses.station_id),
+			AVG(statuses.mechanical + statuses.electric)::FLOAT8 AS bikes_per_station,
+			AVG((statuses.mechanical + statuses.electric = 0)::INTEGER)::FLOAT8 AS empty_share,
+			COALESCE(SUM(statuses.electric)::FLOAT8 / NULLIF(SUM(statuses.mechanical + statuses.electric), 0), 0) AS electric_share
+		FROM statuses
+		JOIN station_areas ON (station_areas.station_id = statuses.station_id)
+		LEFT JOIN communes ON (communes.code = station_areas.commune AND station_areas.%[1]s IS NULL)
+		WHERE statuses.timestamp BETWEEN $1 AND $2
+		GROUP BY station_areas.%[1]s, communes.code, communes.name
+		ORDER BY %[2]s %[3]s, 1
+	`, a.column, order, direction)
+
+	rows, err := db.read.Query(ctx, query, from, to, domain.OutsideParis)
+	if err != nil {
+		return nil, fmt.Errorf("db.read.Query error: %w", classify(err))
+	}
+
+	rank := 0
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Ranking, error) {
+		var ranking domain.Ranking
+		err := row.Scan(
+			&ranking.Name,
+			&ranking.Code,
+			&ranking.OutsideParis,
+			&ranking.Stations,
+			&ranking.BikesPerStation,
+			&ranking.EmptyShare,
+			&ranking.ElectricShare,
+		)
+		if err != nil {
+			return domain.Ranking{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		rank++
+		ranking.Rank = rank
+		return ranking, nil
+	})
+}
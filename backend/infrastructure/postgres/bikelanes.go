@@ -78,12 +78,21 @@ func (db *Database) InsertBikeLanes(ctx context.Context, lanes domain.BikeLanesG
 	return nil
 }
 
-func (db *Database) FetchBikeLanes(ctx context.Context) ([]byte, error) {
-	query := `
-		SELECT JSON_BUILD_OBJECT(
-			'type', 'FeatureCollection',
-			'features', json_agg(ST_AsGeoJSON(t.*)::json)
-		)
+func (db *Database) FetchBikeLanes(ctx context.Context, filter domain.BikeLanesFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.intersects("shape", filter.BBox)
+	if filter.Amenagement != "" {
+		conditions.add("amenagement = %s", filter.Amenagement)
+	}
+	if filter.Arrondissement != "" {
+		conditions.add("arrondissement = %s", filter.Arrondissement)
+	}
+	if filter.Bidirectional != nil {
+		conditions.add("infrastructure_bidirection = %s", *filter.Bidirectional)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*)
 		FROM (
 			SELECT
 				OSMID,
@@ -102,6 +111,7 @@ func (db *Database) FetchBikeLanes(ctx context.Context) ([]byte, error) {
 				vitesse_maximale_autorisee,
 				shape
 			FROM bikelanes
+			WHERE %s
 		) as t(
 			OSMID,
 			name,
@@ -119,11 +129,7 @@ func (db *Database) FetchBikeLanes(ctx context.Context) ([]byte, error) {
 			vitesse_maximale_autorisee,
 			shape
 		)
-	`
+	`, conditions.String())
 
-	var data []byte
-	if err := db.conn.QueryRow(ctx, query).Scan(&data); err != nil {
-		return nil, fmt.Errorf("db.conn.QueryRow error: %w", err)
-	}
-	return data, nil
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
 }
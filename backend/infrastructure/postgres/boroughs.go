@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
@@ -24,34 +25,25 @@ func (db *Database) HasBoroughs(ctx context.Context) (bool, error) {
 	return count != 0, nil
 }
 
-func (db *Database) GetBoroughs(ctx context.Context, timestamp string) ([]byte, error) {
-	if timestamp == "" {
-		tmstp, err := db.maxVelibTimestamp(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("db.maxVelibTimestamp error: %w", err)
-		}
-		timestamp = tmstp
-	}
+func (db *Database) GetBoroughs(ctx context.Context, timestamp time.Time, filter domain.BoundariesFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.add("timestamp = %s", timestamp)
+	conditions.intersects("boroughs.shape", filter.BBox)
+	precision := conditions.bind(filter.Precision)
 
-	query := `
-		SELECT JSON_BUILD_OBJECT(
-			'type', 'FeatureCollection',
-			'features', JSON_AGG(ST_AsGeoJSON(t.*)::json)
-		)
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*, 'shape', %s)
 		FROM (
-			SELECT boroughs.name, boroughs.label, JSON_AGG(id), shape, SUM(statuses.mechanical), SUM(statuses.electric)
+			SELECT boroughs.name, boroughs.label, JSON_AGG(statuses.station_id), shape, SUM(statuses.mechanical), SUM(statuses.electric)
 			FROM boroughs
-			LEFT JOIN stations ON ST_Contains(boroughs.shape, stations.position)
-			JOIN statuses ON (stations.id = statuses.station_id AND timestamp = $1)
+			JOIN station_areas ON (station_areas.borough = boroughs.name)
+			JOIN statuses ON (station_areas.station_id = statuses.station_id)
+			WHERE %s
 			GROUP BY boroughs.name, boroughs.label, shape
 		) as t(name, label, ids, shape, mechanical, electric)
-	`
+	`, precision, conditions.String())
 
-	var data []byte
-	if err := db.conn.QueryRow(ctx, query, timestamp).Scan(&data); err != nil {
-		return nil, fmt.Errorf("db.conn.QueryRow error: %w", err)
-	}
-	return data, nil
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
 }
 
 func (db *Database) InsertBoroughs(ctx context.Context, districts domain.BoroughsGeoJSON) error {
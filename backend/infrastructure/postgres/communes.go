@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+func (db *Database) HasCommunes(ctx context.Context) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM communes
+	`
+
+	var count int64
+	if err := db.conn.QueryRow(ctx, query).Scan(&count); err != nil {
+		return false, fmt.Errorf("db.conn.QueryRow error: %w", err)
+	}
+	return count != 0, nil
+}
+
+func (db *Database) GetCommunes(ctx context.Context, timestamp time.Time, filter domain.BoundariesFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.add("timestamp = %s", timestamp)
+	conditions.intersects("communes.shape", filter.BBox)
+	precision := conditions.bind(filter.Precision)
+
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*, 'shape', %s)
+		FROM (
+			SELECT communes.code, communes.name, JSON_AGG(statuses.station_id), shape, SUM(statuses.mechanical), SUM(statuses.electric)
+			FROM communes
+			JOIN station_areas ON (station_areas.commune = communes.code)
+			JOIN statuses ON (station_areas.station_id = statuses.station_id)
+			WHERE %s
+			GROUP BY communes.code, communes.name, shape
+		) as t(code, name, ids, shape, mechanical, electric)
+	`, precision, conditions.String())
+
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
+}
+
+func (db *Database) InsertCommunes(ctx context.Context, communes domain.CommunesGeoJSON) error {
+	query := `
+		INSERT INTO communes (code, name, shape)
+		VALUES ($1, $2, ST_Multi(ST_GeomFromGeoJSON($3)))
+		ON CONFLICT (code) DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for i := range communes.Features {
+		geojson, err := json.Marshal(&communes.Features[i].Geometry)
+		if err != nil {
+			return fmt.Errorf("json.Marshal error: %w", err)
+		}
+
+		_ = batch.Queue(query,
+			communes.Features[i].Properties.Code,
+			communes.Features[i].Properties.Nom,
+			string(geojson),
+		)
+	}
+
+	results := db.conn.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	for range communes.Features {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("results.Exec error: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+const (
+	codeInvalidDatetimeFormat = "22007"
+	codeDatetimeFieldOverflow = "22008"
+	codeQueryCanceled         = "57014"
+)
+
+// classify wraps err with the domain error it corresponds to, if any, so that
+// callers can tell a missing row or a timeout from a failure.
+func classify(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", domain.ErrTimeout, err)
+	case errors.As(err, &pgErr):
+		switch pgErr.Code {
+		case codeInvalidDatetimeFormat, codeDatetimeFieldOverflow:
+			return fmt.Errorf("%w: %w", domain.ErrInvalidTimestamp, err)
+		case codeQueryCanceled:
+			return fmt.Errorf("%w: %w", domain.ErrTimeout, err)
+		}
+	}
+	return err
+}
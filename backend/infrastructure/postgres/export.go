@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+const exportBatchSize = 10_000
+
+// ExportStatuses streams the statuses matching filter to fn, batch by batch,
+// through a server-side cursor so that exports of any size run in constant
+// memory.
+func (db *Database) ExportStatuses(ctx context.Context, filter domain.StatusesFilter, fn func([]domain.StatusRecord) error) error {
+	declareQuery := `
+		DECLARE statuses_export NO SCROLL CURSOR FOR
+		SELECT
+			statuses.timestamp,
+			stations.id,
+			stations.name,
+			stations.capacity,
+			ST_Y(stations.position),
+			ST_X(stations.position),
+			statuses.mechanical,
+			statuses.electric
+		FROM statuses
+		JOIN stations ON (stations.id = statuses.station_id)
+		WHERE statuses.timestamp BETWEEN $1 AND $2
+			AND (cardinality($3::BIGINT[]) = 0 OR statuses.station_id = ANY($3))
+			AND ($4::TEXT = '' OR EXISTS (
+				SELECT 1
+				FROM station_areas
+				JOIN boroughs ON (boroughs.name = station_areas.borough)
+				WHERE station_areas.station_id = stations.id
+					AND (LOWER(boroughs.name) = LOWER($4) OR LOWER(boroughs.label) = LOWER($4))
+			))
+		ORDER BY statuses.timestamp, statuses.station_id
+	`
+
+	tx, err := db.read.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("db.read.BeginTx error: %w", classify(err))
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	stationIDs := filter.StationIDs
+	if stationIDs == nil {
+		stationIDs = []int{}
+	}
+
+	if _, err := tx.Exec(ctx, declareQuery, filter.From, filter.To, stationIDs, filter.Borough); err != nil {
+		return fmt.Errorf("tx.Exec error: %w", classify(err))
+	}
+
+	fetchQuery := fmt.Sprintf(`FETCH %d FROM statuses_export`, exportBatchSize)
+	for {
+		rows, err := tx.Query(ctx, fetchQuery)
+		if err != nil {
+			return fmt.Errorf("tx.Query error: %w", classify(err))
+		}
+
+		records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.StatusRecord, error) {
+			var record domain.StatusRecord
+			err := row.Scan(
+				&record.Timestamp,
+				&record.StationID,
+				&record.Name,
+				&record.Capacity,
+				&record.Latitude,
+				&record.Longitude,
+				&record.Mechanical,
+				&record.Electric,
+			)
+			if err != nil {
+				return domain.StatusRecord{}, fmt.Errorf("rows.Scan error: %w", err)
+			}
+			return record, nil
+		})
+		if err != nil {
+			return fmt.Errorf("pgx.CollectRows error: %w", classify(err))
+		}
+
+		if len(records) == 0 {
+			return nil
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+	}
+}
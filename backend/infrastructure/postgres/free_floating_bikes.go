@@ -12,8 +12,18 @@ import (
 	"github.com/oupo1337/velibs/backend/domain"
 )
 
-func (db *Database) InsertFreeFloatingBikes(ctx context.Context, bikes []domain.FreeFloatingBike) error {
-	timestamp := time.Now().Truncate(10 * time.Minute)
+func (db *Database) InsertFreeFloatingBikes(ctx context.Context, timestamp time.Time, bikes []domain.FreeFloatingBike) error {
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Begin error: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := beginIngestion(ctx, tx, domain.DatasetFreeFloatingBikes, timestamp); err != nil {
+		return fmt.Errorf("beginIngestion error: %w", err)
+	}
 
 	query := `
 		INSERT INTO free_floating_bikes (timestamp, bike_id, position, is_reserved, is_disabled, current_range_meters, vehicle_type_id, last_reported, vehicle_type)
@@ -36,57 +46,42 @@ func (db *Database) InsertFreeFloatingBikes(ctx context.Context, bikes []domain.
 		)
 	}
 
-	results := db.conn.SendBatch(ctx, batch)
-	defer func() {
-		_ = results.Close()
-	}()
-
-	for range bikes {
-		if _, err := results.Exec(); err != nil {
-			return fmt.Errorf("results.Exec error: %w", err)
-		}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("tx.SendBatch error: %w", err)
 	}
-	return nil
-}
 
-func (db *Database) maxFreeFloatingBikesTimestamp(ctx context.Context) (string, error) {
-	query := `
-		SELECT MAX(timestamp)
-		FROM statuses
-	`
+	if err := endIngestion(ctx, tx, domain.DatasetFreeFloatingBikes, timestamp); err != nil {
+		return fmt.Errorf("endIngestion error: %w", err)
+	}
 
-	var timestamp time.Time
-	err := db.conn.QueryRow(ctx, query).Scan(&timestamp)
-	if err != nil {
-		return "", fmt.Errorf("conn.Query error: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit error: %w", err)
 	}
-	return timestamp.Format(time.RFC3339), nil
+	return nil
 }
 
-func (db *Database) FetchFreeFloatingBikes(ctx context.Context, timestamp string) ([]byte, error) {
-	if timestamp == "" {
-		tmstp, err := db.maxFreeFloatingBikesTimestamp(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("db.maxFreeFloatingBikesTimestamp error: %w", err)
-		}
-		timestamp = tmstp
+func (db *Database) FetchFreeFloatingBikes(ctx context.Context, timestamp time.Time, filter domain.FreeFloatingBikesFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.add("timestamp = %s", timestamp)
+	conditions.intersects("position", filter.BBox)
+	if filter.IsDisabled != nil {
+		conditions.add("is_disabled = %s", *filter.IsDisabled)
+	}
+	if filter.MinRange != nil {
+		conditions.add("current_range_meters >= %s", *filter.MinRange)
+	}
+	if filter.VehicleType != "" {
+		conditions.add("vehicle_type = %s", filter.VehicleType)
 	}
 
-	query := `
-		SELECT json_build_object(
-			'type', 'FeatureCollection',
-			'features', json_agg(ST_AsGeoJSON(t.*)::json)
-		)
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*)
 		FROM (
 			SELECT bike_id, position, is_reserved, is_disabled, current_range_meters, vehicle_type_id, last_reported, vehicle_type
 			FROM free_floating_bikes
-			WHERE timestamp = $1
+			WHERE %s
 		) as t(bike_id, position, is_reserved, is_disabled, current_range_meters, vehicle_type_id, last_reported, vehicle_type)
-	`
+	`, conditions.String())
 
-	var data []byte
-	if err := db.conn.QueryRow(ctx, query, timestamp).Scan(&data); err != nil {
-		return nil, fmt.Errorf("db.conn.QueryRow error: %w", err)
-	}
-	return data, nil
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
 }
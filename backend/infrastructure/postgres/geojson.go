@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// FeatureFunc receives the features of a query one by one, encoded as GeoJSON.
+type FeatureFunc func(feature []byte) error
+
+// streamFeatures runs a query returning one GeoJSON feature per row and hands
+// them to fn as they are read, without buffering the whole collection.
+func (db *Database) streamFeatures(ctx context.Context, fn FeatureFunc, query string, args ...any) error {
+	rows, err := db.read.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("db.read.Query error: %w", classify(err))
+	}
+
+	var feature []byte
+	if _, err := pgx.ForEachRow(rows, []any{&feature}, func() error {
+		return fn(feature)
+	}); err != nil {
+		return fmt.Errorf("pgx.ForEachRow error: %w", classify(err))
+	}
+	return nil
+}
+
+// where builds the conditions of a query from the filters that were given,
+// leaving the others out of the plan so that the indexes can be used.
+type where struct {
+	conditions []string
+	args       []any
+}
+
+// bind adds an argument to the query and returns its placeholder.
+func (w *where) bind(arg any) string {
+	w.args = append(w.args, arg)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// add appends a condition whose %s verbs are the placeholders of args.
+func (w *where) add(condition string, args ...any) {
+	placeholders := make([]any, len(args))
+	for i := range args {
+		placeholders[i] = w.bind(args[i])
+	}
+	w.conditions = append(w.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+// intersects keeps the rows whose column intersects bbox, when given.
+func (w *where) intersects(column string, bbox *domain.BoundingBox) {
+	if bbox == nil {
+		return
+	}
+	w.add(column+" && ST_MakeEnvelope(%s, %s, %s, %s, 4326)", bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
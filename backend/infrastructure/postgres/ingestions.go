@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSlotAlreadyIngested = errors.New("slot already ingested")
+
+// beginIngestion locks the dataset's ingestion row for the duration of tx and
+// records a gap when slots were missed since the last successful ingestion.
+func beginIngestion(ctx context.Context, tx pgx.Tx, dataset string, slot time.Time) error {
+	lockQuery := `
+		SELECT COALESCE(last_slot >= $2, FALSE)
+		FROM ingestions
+		WHERE dataset = $1
+		FOR UPDATE
+	`
+
+	var ingested bool
+	if err := tx.QueryRow(ctx, lockQuery, dataset, slot).Scan(&ingested); err != nil {
+		return fmt.Errorf("tx.QueryRow error: %w", err)
+	}
+	if ingested {
+		return ErrSlotAlreadyIngested
+	}
+
+	gapQuery := `
+		INSERT INTO data_gaps (dataset, start_slot, end_slot)
+		SELECT dataset, last_slot + interval '10 minutes', $2::timestamptz - interval '10 minutes'
+		FROM ingestions
+		WHERE dataset = $1 AND last_slot < $2::timestamptz - interval '10 minutes'
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := tx.Exec(ctx, gapQuery, dataset, slot); err != nil {
+		return fmt.Errorf("tx.Exec error: %w", err)
+	}
+	return nil
+}
+
+func endIngestion(ctx context.Context, tx pgx.Tx, dataset string, slot time.Time) error {
+	query := `
+		UPDATE ingestions
+		SET last_slot = $2
+		WHERE dataset = $1
+	`
+
+	if _, err := tx.Exec(ctx, query, dataset, slot); err != nil {
+		return fmt.Errorf("tx.Exec error: %w", err)
+	}
+
+	if err := notifySnapshot(ctx, tx, dataset, slot); err != nil {
+		return fmt.Errorf("notifySnapshot error: %w", err)
+	}
+	return nil
+}
+
+func (db *Database) LastIngestedSlot(ctx context.Context, dataset string) (time.Time, error) {
+	query := `
+		SELECT last_slot
+		FROM ingestions
+		WHERE dataset = $1
+	`
+
+	var slot *time.Time
+	if err := db.read.QueryRow(ctx, query, dataset).Scan(&slot); err != nil {
+		return time.Time{}, fmt.Errorf("db.read.QueryRow error: %w", err)
+	}
+	if slot == nil {
+		return time.Time{}, nil
+	}
+	return *slot, nil
+}
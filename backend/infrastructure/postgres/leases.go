@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *Database) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO job_leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE job_leases.expires_at < NOW() OR job_leases.holder = EXCLUDED.holder
+	`
+
+	tag, err := db.conn.Exec(ctx, query, name, holder, ttl.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("db.conn.Exec error: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (db *Database) ReleaseLease(ctx context.Context, name, holder string) error {
+	query := `
+		UPDATE job_leases
+		SET expires_at = NOW()
+		WHERE name = $1 AND holder = $2
+	`
+
+	if _, err := db.conn.Exec(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("db.conn.Exec error: %w", err)
+	}
+	return nil
+}
+
+func (db *Database) LeaseHolder(ctx context.Context, name string) (string, error) {
+	query := `
+		SELECT holder
+		FROM job_leases
+		WHERE name = $1 AND expires_at > NOW()
+	`
+
+	var holder string
+	err := db.conn.QueryRow(ctx, query, name).Scan(&holder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("db.conn.QueryRow error: %w", err)
+	}
+	return holder, nil
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.acquiredConns
+	ch <- p.idleConns
+	ch <- p.totalConns
+	ch <- p.maxConns
+	ch <- p.acquireCount
+	ch <- p.acquireDuration
+	ch <- p.emptyAcquireCount
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(p.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(p.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(p.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(p.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
+
+func newPoolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("velib", "db_pool", name), help, nil, nil)
+}
+
+func (db *Database) Collector() prometheus.Collector {
+	return &poolCollector{
+		pool:              db.conn,
+		acquiredConns:     newPoolDesc("acquired_connections", "Number of connections currently in use."),
+		idleConns:         newPoolDesc("idle_connections", "Number of idle connections in the pool."),
+		totalConns:        newPoolDesc("total_connections", "Total number of connections in the pool."),
+		maxConns:          newPoolDesc("max_connections", "Maximum size of the pool."),
+		acquireCount:      newPoolDesc("acquire_total", "Number of successful connection acquisitions."),
+		acquireDuration:   newPoolDesc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquireCount: newPoolDesc("empty_acquire_total", "Number of acquisitions that had to wait for a connection."),
+	}
+}
@@ -0,0 +1,311 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres/migrations"
+)
+
+var ErrIncompatibleSchema = errors.New("incompatible schema version")
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+func schemaVersion(ctx context.Context, conn *pgxpool.Pool) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(version), 0)
+		FROM schema_migrations
+	`
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("conn.QueryRow error: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := conn.QueryRow(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("conn.QueryRow error: %w", err)
+	}
+	return version, nil
+}
+
+// checkSchema fails when the database is older than the binary. Newer schemas
+// are accepted, so that the previous release keeps running while a rollout
+// that migrated the database is in progress.
+func checkSchema(ctx context.Context, conn *pgxpool.Pool) error {
+	expected, err := migrations.Latest()
+	if err != nil {
+		return fmt.Errorf("migrations.Latest error: %w", err)
+	}
+
+	version, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("schemaVersion error: %w", err)
+	}
+
+	if version < expected {
+		return fmt.Errorf("%w: database is at version %d, expected at least %d, run `migrate up`", ErrIncompatibleSchema, version, expected)
+	}
+	return nil
+}
+
+// withMigrationLock runs fn on a single connection holding an advisory lock,
+// so that concurrent replicas don't migrate at the same time.
+func (db *Database) withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := db.conn.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Acquire error: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock(hashtext('velib_migrations'))`); err != nil {
+		return fmt.Errorf("conn.Exec error: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext('velib_migrations'))`)
+	}()
+
+	createQuery := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     INTEGER PRIMARY KEY,
+			name        TEXT NOT NULL,
+			applied_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+
+	if _, err := conn.Exec(ctx, createQuery); err != nil {
+		return fmt.Errorf("conn.Exec error: %w", err)
+	}
+
+	if err := baselineFromSqitch(ctx, conn); err != nil {
+		return fmt.Errorf("baselineFromSqitch error: %w", err)
+	}
+	return fn(conn)
+}
+
+// baselineFromSqitch marks as applied the changes deployed by sqitch before
+// the migrations were embedded in the binaries.
+func baselineFromSqitch(ctx context.Context, conn *pgxpool.Conn) error {
+	var hasSqitch bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('sqitch.changes') IS NOT NULL`).Scan(&hasSqitch); err != nil {
+		return fmt.Errorf("conn.QueryRow error: %w", err)
+	}
+	if !hasSqitch {
+		return nil
+	}
+
+	var hasMigrations bool
+	if err := conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations)`).Scan(&hasMigrations); err != nil {
+		return fmt.Errorf("conn.QueryRow error: %w", err)
+	}
+	if hasMigrations {
+		return nil
+	}
+
+	all, err := migrations.Load()
+	if err != nil {
+		return fmt.Errorf("migrations.Load error: %w", err)
+	}
+
+	query := `
+		INSERT INTO schema_migrations (version, name)
+		SELECT $1, $2
+		WHERE EXISTS (SELECT 1 FROM sqitch.changes WHERE project = 'velib' AND change = $2)
+	`
+
+	for _, m := range all {
+		if _, err := conn.Exec(ctx, query, m.Version, m.Name); err != nil {
+			return fmt.Errorf("conn.Exec error: %w", err)
+		}
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("conn.Query error: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (db *Database) MigrateUp(ctx context.Context) ([]migrations.Migration, error) {
+	all, err := migrations.Load()
+	if err != nil {
+		return nil, fmt.Errorf("migrations.Load error: %w", err)
+	}
+
+	var done []migrations.Migration
+	err = db.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("appliedVersions error: %w", err)
+		}
+
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.Up); err != nil {
+					return fmt.Errorf("tx.Exec error: %w", err)
+				}
+				if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+					return fmt.Errorf("tx.Exec error: %w", err)
+				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (db *Database) MigrateDown(ctx context.Context, steps int) ([]migrations.Migration, error) {
+	all, err := migrations.Load()
+	if err != nil {
+		return nil, fmt.Errorf("migrations.Load error: %w", err)
+	}
+
+	var done []migrations.Migration
+	err = db.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("appliedVersions error: %w", err)
+		}
+
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			m := all[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.Down); err != nil {
+					return fmt.Errorf("tx.Exec error: %w", err)
+				}
+				if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+					return fmt.Errorf("tx.Exec error: %w", err)
+				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (db *Database) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	all, err := migrations.Load()
+	if err != nil {
+		return nil, fmt.Errorf("migrations.Load error: %w", err)
+	}
+
+	var statuses []MigrationStatus
+	err = db.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("appliedVersions error: %w", err)
+		}
+
+		for _, m := range all {
+			status := MigrationStatus{
+				Version: m.Version,
+				Name:    m.Name,
+			}
+			if appliedAt, ok := applied[m.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Migrate implements the `migrate up|down [steps]|status` subcommand shared by
+// the services.
+func Migrate(ctx context.Context, conf Configuration, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [steps]|status")
+	}
+
+	conf.SkipSchemaCheck = true
+	db, err := New(conf)
+	if err != nil {
+		return fmt.Errorf("postgres.New error: %w", err)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		done, err := db.MigrateUp(ctx)
+		for _, m := range done {
+			_, _ = fmt.Fprintf(out, "applied %s\n", m.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		done, err := db.MigrateDown(ctx, steps)
+		for _, m := range done {
+			_, _ = fmt.Fprintf(out, "reverted %s\n", m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
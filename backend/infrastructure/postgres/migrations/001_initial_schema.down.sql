@@ -0,0 +1,2 @@
+DROP TABLE statuses;
+DROP TABLE stations;
@@ -1,7 +1,3 @@
--- Deploy velib:001_initial_schema to pg
-
-BEGIN;
-
 CREATE TABLE stations (
     id          BIGINT PRIMARY KEY,
     name        TEXT NOT NULL,
@@ -19,5 +15,3 @@ CREATE TABLE statuses (
 CREATE INDEX statuses_station_id_timestamp_idx ON statuses (station_id, timestamp DESC);
 CREATE INDEX statuses_timestamp_idx ON statuses (timestamp);
 CREATE INDEX statuses_station_id_idx ON statuses (station_id);
-
-COMMIT;
@@ -1,7 +1,3 @@
--- Deploy velib:002_bikeways to pg
-
-BEGIN;
-
 CREATE TABLE bikeways (
     typology                TEXT NOT NULL,
     bidirectional           BOOLEAN,
@@ -10,5 +6,3 @@ CREATE TABLE bikeways (
     route                   TEXT,
     shape                   GEOMETRY(LINESTRING, 4326) NOT NULL
 );
-
-COMMIT;
@@ -0,0 +1 @@
+DROP TABLE administrative_districts;
@@ -1,7 +1,3 @@
--- Revert velib:006_bikelanes from pg
-
-BEGIN;
-
 CREATE TABLE bikeways (
     typology                TEXT NOT NULL,
     bidirectional           BOOLEAN,
@@ -12,5 +8,3 @@ CREATE TABLE bikeways (
 );
 
 DROP TABLE bikelanes;
-
-COMMIT;
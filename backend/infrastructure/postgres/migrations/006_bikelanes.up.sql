@@ -1,7 +1,3 @@
--- Deploy velib:006_bikelanes to pg
-
-BEGIN;
-
 CREATE TABLE bikelanes (
     OSMID                       INTEGER NOT NULL,
     name                        TEXT NOT NULL,
@@ -21,5 +17,3 @@ CREATE TABLE bikelanes (
 );
 
 DROP TABLE bikeways;
-
-COMMIT;
\ No newline at end of file
@@ -1,5 +1 @@
-BEGIN;
-
 DROP TABLE free_floating_bikes;
-
-COMMIT;
\ No newline at end of file
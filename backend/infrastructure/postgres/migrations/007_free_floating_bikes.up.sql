@@ -1,5 +1,3 @@
-BEGIN;
-
 CREATE TABLE free_floating_bikes (
     timestamp               TIMESTAMP NOT NULL,
     bike_id                 UUID NOT NULL,
@@ -15,5 +13,3 @@ CREATE TABLE free_floating_bikes (
 CREATE INDEX free_floating_bikes_timestamp_idx ON free_floating_bikes (timestamp);
 CREATE INDEX free_floating_bikes_bike_id_idx ON free_floating_bikes (bike_id);
 CREATE INDEX free_floating_bikes_bike_timestamp_id_idx ON free_floating_bikes (timestamp, bike_id);
-
-COMMIT;
\ No newline at end of file
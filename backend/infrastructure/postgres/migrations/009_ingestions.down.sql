@@ -0,0 +1,2 @@
+DROP TABLE data_gaps;
+DROP TABLE ingestions;
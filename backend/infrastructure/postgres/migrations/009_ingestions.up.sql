@@ -0,0 +1,18 @@
+CREATE TABLE ingestions (
+    dataset     TEXT PRIMARY KEY,
+    last_slot   TIMESTAMP
+);
+
+INSERT INTO ingestions (dataset, last_slot)
+SELECT 'statuses', MAX(timestamp) FROM statuses;
+
+INSERT INTO ingestions (dataset, last_slot)
+SELECT 'free_floating_bikes', MAX(timestamp) FROM free_floating_bikes;
+
+CREATE TABLE data_gaps (
+    dataset     TEXT NOT NULL REFERENCES ingestions(dataset),
+    start_slot  TIMESTAMP NOT NULL,
+    end_slot    TIMESTAMP NOT NULL,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (dataset, start_slot)
+);
@@ -0,0 +1,2 @@
+DROP TABLE fetch_quality;
+DROP TABLE quarantined_records;
@@ -0,0 +1,18 @@
+CREATE TABLE quarantined_records (
+    dataset     TEXT NOT NULL,
+    timestamp   TIMESTAMP NOT NULL,
+    rule        TEXT NOT NULL,
+    reason      TEXT NOT NULL,
+    payload     JSONB NOT NULL
+);
+
+CREATE INDEX quarantined_records_dataset_timestamp_idx ON quarantined_records (dataset, timestamp);
+
+CREATE TABLE fetch_quality (
+    dataset     TEXT NOT NULL,
+    timestamp   TIMESTAMP NOT NULL,
+    received    INTEGER NOT NULL,
+    accepted    INTEGER NOT NULL,
+    rejected    INTEGER NOT NULL,
+    PRIMARY KEY (dataset, timestamp)
+);
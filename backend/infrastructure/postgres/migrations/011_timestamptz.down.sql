@@ -0,0 +1,20 @@
+ALTER TABLE fetch_quality
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC';
+
+ALTER TABLE quarantined_records
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC';
+
+ALTER TABLE data_gaps
+    ALTER COLUMN start_slot TYPE TIMESTAMP USING start_slot AT TIME ZONE 'UTC',
+    ALTER COLUMN end_slot TYPE TIMESTAMP USING end_slot AT TIME ZONE 'UTC',
+    ALTER COLUMN detected_at TYPE TIMESTAMP USING detected_at AT TIME ZONE 'UTC';
+
+ALTER TABLE ingestions
+    ALTER COLUMN last_slot TYPE TIMESTAMP USING last_slot AT TIME ZONE 'UTC';
+
+ALTER TABLE free_floating_bikes
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN last_reported TYPE TIMESTAMP USING last_reported AT TIME ZONE 'UTC';
+
+ALTER TABLE statuses
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC';
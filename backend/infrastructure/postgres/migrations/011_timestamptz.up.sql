@@ -0,0 +1,21 @@
+-- The fetcher has always run with a UTC clock, so existing values are UTC.
+ALTER TABLE statuses
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC';
+
+ALTER TABLE free_floating_bikes
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN last_reported TYPE TIMESTAMPTZ USING last_reported AT TIME ZONE 'UTC';
+
+ALTER TABLE ingestions
+    ALTER COLUMN last_slot TYPE TIMESTAMPTZ USING last_slot AT TIME ZONE 'UTC';
+
+ALTER TABLE data_gaps
+    ALTER COLUMN start_slot TYPE TIMESTAMPTZ USING start_slot AT TIME ZONE 'UTC',
+    ALTER COLUMN end_slot TYPE TIMESTAMPTZ USING end_slot AT TIME ZONE 'UTC',
+    ALTER COLUMN detected_at TYPE TIMESTAMPTZ USING detected_at AT TIME ZONE 'UTC';
+
+ALTER TABLE quarantined_records
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC';
+
+ALTER TABLE fetch_quality
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC';
@@ -0,0 +1 @@
+DROP INDEX bikelanes_gist;
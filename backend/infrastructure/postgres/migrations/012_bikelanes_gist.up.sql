@@ -0,0 +1 @@
+CREATE INDEX bikelanes_gist ON bikelanes USING GIST (shape);
@@ -0,0 +1,40 @@
+CREATE TABLE alerts (
+    id              UUID PRIMARY KEY,
+    station_ids     BIGINT[] NOT NULL,
+    metric          TEXT NOT NULL,
+    operator        TEXT NOT NULL,
+    threshold       INTEGER NOT NULL,
+    duration        INTERVAL NOT NULL,
+    cooldown        INTERVAL NOT NULL,
+    webhook_url     TEXT NOT NULL,
+    secret          TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One row per alert and station whose condition holds, or held, since the
+-- alert was created.
+CREATE TABLE alert_states (
+    alert_id        UUID NOT NULL REFERENCES alerts (id) ON DELETE CASCADE,
+    station_id      BIGINT NOT NULL,
+    matching_since  TIMESTAMPTZ,
+    last_triggered  TIMESTAMPTZ,
+    PRIMARY KEY (alert_id, station_id)
+);
+
+CREATE TABLE alert_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    alert_id        UUID NOT NULL REFERENCES alerts (id) ON DELETE CASCADE,
+    station_id      BIGINT NOT NULL,
+    timestamp       TIMESTAMPTZ NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status_code     INTEGER,
+    error           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX alert_deliveries_alert_id_idx ON alert_deliveries (alert_id, id);
+CREATE INDEX alert_deliveries_pending_idx ON alert_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1 @@
+DROP TABLE station_areas;
@@ -0,0 +1,18 @@
+-- The borough and the district of every station, so that aggregates don't
+-- have to find them with ST_Contains on every request. Kept up to date by the
+-- fetcher whenever stations or areas are loaded.
+CREATE TABLE station_areas (
+    station_id  BIGINT PRIMARY KEY REFERENCES stations (id) ON DELETE CASCADE,
+    borough     TEXT,
+    district    TEXT
+);
+
+CREATE INDEX station_areas_borough_idx ON station_areas (borough);
+CREATE INDEX station_areas_district_idx ON station_areas (district);
+
+INSERT INTO station_areas (station_id, borough, district)
+SELECT
+    stations.id,
+    (SELECT name FROM boroughs WHERE ST_Contains(boroughs.shape, stations.position) LIMIT 1),
+    (SELECT name FROM administrative_districts WHERE ST_Contains(administrative_districts.shape, stations.position) LIMIT 1)
+FROM stations;
@@ -0,0 +1,2 @@
+ALTER TABLE station_areas DROP COLUMN commune;
+DROP TABLE communes;
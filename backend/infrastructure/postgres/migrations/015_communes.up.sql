@@ -0,0 +1,12 @@
+-- The communes around Paris, so that the stations of Vélib' Métropole outside
+-- of the boroughs can be told apart.
+CREATE TABLE communes (
+    code    TEXT PRIMARY KEY,
+    name    TEXT NOT NULL,
+    shape   GEOMETRY(MULTIPOLYGON, 4326) NOT NULL
+);
+
+CREATE INDEX communes_gist ON communes USING GIST (shape);
+
+ALTER TABLE station_areas ADD COLUMN commune TEXT;
+CREATE INDEX station_areas_commune_idx ON station_areas (commune);
@@ -0,0 +1,4 @@
+-- The caller that created the alert, as named by its API key. Alerts created
+-- before keys were required have no owner and can't be reached from the api.
+ALTER TABLE alerts ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+CREATE INDEX alerts_owner_idx ON alerts (owner);
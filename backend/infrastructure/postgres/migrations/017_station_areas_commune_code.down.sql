@@ -0,0 +1,6 @@
+ALTER TABLE station_areas DROP CONSTRAINT station_areas_commune_fkey;
+
+UPDATE station_areas
+SET commune = communes.name
+FROM communes
+WHERE communes.code = station_areas.commune;
@@ -0,0 +1,13 @@
+-- Stations refer to their commune by code, names aren't unique among the
+-- communes.
+UPDATE station_areas
+SET commune = (
+    SELECT communes.code
+    FROM stations
+    JOIN communes ON ST_Contains(communes.shape, stations.position)
+    WHERE stations.id = station_areas.station_id
+    LIMIT 1
+);
+
+ALTER TABLE station_areas
+    ADD CONSTRAINT station_areas_commune_fkey FOREIGN KEY (commune) REFERENCES communes (code) ON DELETE SET NULL;
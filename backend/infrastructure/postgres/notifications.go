@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// SnapshotsChannel receives a domain.Snapshot for every slot ingested.
+const SnapshotsChannel = "snapshots"
+
+// notifySnapshot is only delivered to the listeners once tx commits.
+func notifySnapshot(ctx context.Context, tx pgx.Tx, dataset string, slot time.Time) error {
+	payload, err := json.Marshal(domain.Snapshot{Dataset: dataset, Timestamp: slot})
+	if err != nil {
+		return fmt.Errorf("json.Marshal error: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, SnapshotsChannel, string(payload)); err != nil {
+		return fmt.Errorf("tx.Exec error: %w", err)
+	}
+	return nil
+}
+
+// Listen hands the payload of the notifications of channel to fn until ctx is
+// done or the connection is lost. Notifications aren't replicated, they are
+// listened for on the primary.
+func (db *Database) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	pooled, err := db.conn.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Acquire error: %w", err)
+	}
+
+	// The connection keeps listening until it is closed, it can't go back to
+	// the pool.
+	conn := pooled.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("conn.Exec error: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("conn.WaitForNotification error: %w", err)
+		}
+		fn(notification.Payload)
+	}
+}
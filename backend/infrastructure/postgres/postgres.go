@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/exaring/otelpgx"
@@ -14,34 +16,143 @@ type Configuration struct {
 	Password string
 	Address  string
 	Name     string
+
+	// ReadAddress points to a read replica used for the api's read-only
+	// queries, the primary is used when empty.
+	ReadAddress string
+
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	MinConns        int32
+	MaxConns        int32
+	MaxConnIdleTime time.Duration
+	MaxConnLifetime time.Duration
+
+	StatementTimeout time.Duration
+	ApplicationName  string
+
+	// SkipSchemaCheck lets New connect to a database older than the embedded
+	// migrations, which is only wanted to run them.
+	SkipSchemaCheck bool
 }
 
 type Database struct {
 	conn *pgxpool.Pool
+	read *pgxpool.Pool
 }
 
-func New(conf Configuration) (*Database, error) {
-	connString := fmt.Sprintf("postgres://%s:%s@%s/%s", conf.Username, conf.Password, conf.Address, conf.Name)
+func (conf Configuration) connString(address string) string {
+	query := url.Values{}
+	if conf.SSLMode != "" {
+		query.Set("sslmode", conf.SSLMode)
+	}
+	if conf.SSLRootCert != "" {
+		query.Set("sslrootcert", conf.SSLRootCert)
+	}
+	if conf.SSLCert != "" {
+		query.Set("sslcert", conf.SSLCert)
+	}
+	if conf.SSLKey != "" {
+		query.Set("sslkey", conf.SSLKey)
+	}
 
-	cfg, err := pgxpool.ParseConfig(connString)
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(conf.Username, conf.Password),
+		Host:     address,
+		Path:     "/" + conf.Name,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+func newPool(ctx context.Context, conf Configuration, address string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(conf.connString(address))
 	if err != nil {
 		return nil, fmt.Errorf("create connection pool error: %w", err)
 	}
 	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
 
+	if conf.MaxConns > 0 {
+		cfg.MaxConns = conf.MaxConns
+	}
+	cfg.MinConns = conf.MinConns
+	if conf.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = conf.MaxConnIdleTime
+	}
+	if conf.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = conf.MaxConnLifetime
+	}
+
+	if conf.ApplicationName != "" {
+		cfg.ConnConfig.RuntimeParams["application_name"] = conf.ApplicationName
+	}
+	if conf.StatementTimeout > 0 {
+		cfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database error: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pool.Ping error: %w", err)
+	}
+	return pool, nil
+}
+
+func New(conf Configuration) (*Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
 
-	conn, err := pgxpool.NewWithConfig(ctx, cfg)
+	conn, err := newPool(ctx, conf, conf.Address)
 	if err != nil {
-		return nil, fmt.Errorf("connect to database error: %w", err)
+		return nil, fmt.Errorf("newPool error: %w", err)
 	}
 
-	if err := conn.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("conn.Ping error: %w", err)
+	if !conf.SkipSchemaCheck {
+		if err := checkSchema(ctx, conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("checkSchema error: %w", err)
+		}
+	}
+
+	read := conn
+	if conf.ReadAddress != "" {
+		read, err = newPool(ctx, conf, conf.ReadAddress)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("newPool replica error: %w", err)
+		}
 	}
 
 	return &Database{
 		conn: conn,
+		read: read,
 	}, nil
 }
+
+func (db *Database) Ping(ctx context.Context) error {
+	if err := db.conn.Ping(ctx); err != nil {
+		return fmt.Errorf("conn.Ping error: %w", err)
+	}
+
+	if db.read != db.conn {
+		if err := db.read.Ping(ctx); err != nil {
+			return fmt.Errorf("read.Ping error: %w", err)
+		}
+	}
+	return nil
+}
+
+func (db *Database) Close() {
+	if db.read != db.conn {
+		db.read.Close()
+	}
+	db.conn.Close()
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+func (db *Database) GetStationCapacities(ctx context.Context) (map[int64]int, error) {
+	query := `
+		SELECT id, capacity
+		FROM stations
+	`
+
+	rows, err := db.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("conn.Query error: %w", err)
+	}
+	defer rows.Close()
+
+	capacities := make(map[int64]int)
+	for rows.Next() {
+		var id int64
+		var capacity int
+		if err := rows.Scan(&id, &capacity); err != nil {
+			return nil, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		capacities[id] = capacity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err error: %w", err)
+	}
+	return capacities, nil
+}
+
+func (db *Database) InsertFetchQuality(ctx context.Context, quality domain.FetchQuality, records []domain.QuarantinedRecord) error {
+	qualityQuery := `
+		INSERT INTO fetch_quality (dataset, timestamp, received, accepted, rejected)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (dataset, timestamp) DO UPDATE
+		SET received = EXCLUDED.received, accepted = EXCLUDED.accepted, rejected = EXCLUDED.rejected
+	`
+
+	quarantineQuery := `
+		INSERT INTO quarantined_records (dataset, timestamp, rule, reason, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	batch := &pgx.Batch{}
+	_ = batch.Queue(qualityQuery,
+		quality.Dataset,
+		quality.Timestamp,
+		quality.Received,
+		quality.Accepted,
+		quality.Rejected,
+	)
+
+	for i := range records {
+		payload, err := json.Marshal(records[i].Payload)
+		if err != nil {
+			return fmt.Errorf("json.Marshal error: %w", err)
+		}
+
+		_ = batch.Queue(quarantineQuery,
+			quality.Dataset,
+			quality.Timestamp,
+			records[i].Rule,
+			records[i].Reason,
+			payload,
+		)
+	}
+
+	if err := db.conn.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("db.conn.SendBatch error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// snapshotTables are the tables holding a snapshot per slot, by dataset.
+var snapshotTables = map[string]string{
+	domain.DatasetStatuses:          "statuses",
+	domain.DatasetFreeFloatingBikes: "free_floating_bikes",
+}
+
+func snapshotTable(dataset string) (string, error) {
+	table, ok := snapshotTables[dataset]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown dataset %q", domain.ErrNotFound, dataset)
+	}
+	return table, nil
+}
+
+func snapQuery(table string, snap domain.Snap) (string, error) {
+	before := fmt.Sprintf(`SELECT timestamp FROM %s WHERE timestamp <= $1 ORDER BY timestamp DESC LIMIT 1`, table)
+	after := fmt.Sprintf(`SELECT timestamp FROM %s WHERE timestamp >= $1 ORDER BY timestamp ASC LIMIT 1`, table)
+
+	switch snap {
+	case domain.SnapBefore:
+		return before, nil
+	case domain.SnapAfter:
+		return after, nil
+	case domain.SnapNearest:
+		return fmt.Sprintf(`
+			SELECT timestamp
+			FROM ((%s) UNION ALL (%s)) AS candidates
+			ORDER BY ABS(EXTRACT(EPOCH FROM timestamp - $1)), timestamp
+			LIMIT 1
+		`, before, after), nil
+	default:
+		return "", fmt.Errorf("unknown snap %q", snap)
+	}
+}
+
+// SnapshotTimestamp resolves the slot of dataset served for a requested
+// timestamp, the latest one when timestamp is zero.
+func (db *Database) SnapshotTimestamp(ctx context.Context, dataset string, timestamp time.Time, snap domain.Snap) (time.Time, error) {
+	table, err := snapshotTable(dataset)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if timestamp.IsZero() {
+		return db.latestSlot(ctx, dataset)
+	}
+
+	query, err := snapQuery(table, snap)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("snapQuery error: %w", err)
+	}
+
+	var snapped time.Time
+	if err := db.read.QueryRow(ctx, query, timestamp).Scan(&snapped); err != nil {
+		return time.Time{}, fmt.Errorf("db.read.QueryRow error: %w", classify(err))
+	}
+	return snapped, nil
+}
+
+// latestSlot is the last slot recorded by the ingestion of dataset, the
+// table is only looked at when the ingestion has no slot yet.
+func (db *Database) latestSlot(ctx context.Context, dataset string) (time.Time, error) {
+	last, err := db.LastIngestedSlot(ctx, dataset)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("db.LastIngestedSlot error: %w", classify(err))
+	}
+	if !last.IsZero() {
+		return last, nil
+	}
+
+	_, last, err = db.TimestampBounds(ctx, dataset)
+	return last, err
+}
+
+// TimestampBounds returns the first and the last slots of dataset. MIN and MAX
+// are kept alone so that they are read from the ends of the timestamp index.
+func (db *Database) TimestampBounds(ctx context.Context, dataset string) (time.Time, time.Time, error) {
+	table, err := snapshotTable(dataset)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT MIN(timestamp), MAX(timestamp)
+		FROM %s
+	`, table)
+
+	var first, last *time.Time
+	if err := db.read.QueryRow(ctx, query).Scan(&first, &last); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("db.read.QueryRow error: %w", classify(err))
+	}
+	if first == nil || last == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: no %s yet", domain.ErrNotFound, dataset)
+	}
+	return *first, *last, nil
+}
+
+// GetSlots returns the slots of dataset with data between from and to included.
+func (db *Database) GetSlots(ctx context.Context, dataset string, from, to time.Time) ([]time.Time, error) {
+	table, err := snapshotTable(dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT timestamp
+		FROM %s
+		WHERE timestamp BETWEEN $1 AND $2
+		GROUP BY timestamp
+		ORDER BY timestamp
+	`, table)
+
+	rows, err := db.read.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("db.read.Query error: %w", classify(err))
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[time.Time])
+}
+
+// GetGaps returns the gaps recorded by the ingestion of dataset overlapping
+// the range between from and to.
+func (db *Database) GetGaps(ctx context.Context, dataset string, from, to time.Time) ([]domain.Gap, error) {
+	query := `
+		SELECT start_slot, end_slot
+		FROM data_gaps
+		WHERE dataset = $1 AND start_slot <= $3 AND end_slot >= $2
+		ORDER BY start_slot
+	`
+
+	rows, err := db.read.Query(ctx, query, dataset, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("db.read.Query error: %w", classify(err))
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Gap, error) {
+		var gap domain.Gap
+		if err := row.Scan(&gap.Start, &gap.End); err != nil {
+			return domain.Gap{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return gap, nil
+	})
+}
@@ -12,20 +12,6 @@ import (
 	"github.com/oupo1337/velibs/backend/domain"
 )
 
-func (db *Database) maxVelibTimestamp(ctx context.Context) (string, error) {
-	query := `
-		SELECT MAX(timestamp)
-		FROM statuses
-	`
-
-	var timestamp time.Time
-	err := db.conn.QueryRow(ctx, query).Scan(&timestamp)
-	if err != nil {
-		return "", fmt.Errorf("conn.Query error: %w", err)
-	}
-	return timestamp.Format(time.RFC3339), nil
-}
-
 func (db *Database) InsertStations(ctx context.Context, stationsInformation []domain.StationInformation) error {
 	query := `
 		INSERT INTO stations (id, name, capacity, position)
@@ -54,80 +40,104 @@ func (db *Database) InsertStations(ctx context.Context, stationsInformation []do
 	return nil
 }
 
-func (db *Database) InsertStatuses(ctx context.Context, statuses []domain.StationStatus) error {
-	timestamp := time.Now().Truncate(10 * time.Minute)
+func (db *Database) InsertStatuses(ctx context.Context, timestamp time.Time, statuses []domain.StationStatus) error {
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db.conn.Begin error: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := beginIngestion(ctx, tx, domain.DatasetStatuses, timestamp); err != nil {
+		return fmt.Errorf("beginIngestion error: %w", err)
+	}
 
-	_, err := db.conn.CopyFrom(ctx,
+	_, err = tx.CopyFrom(ctx,
 		pgx.Identifier{"statuses"},
 		[]string{"timestamp", "station_id", "mechanical", "electric"},
 		pgx.CopyFromSlice(len(statuses), func(i int) ([]any, error) {
-			mechanical := 0
-			electric := 0
-
-			for _, available := range statuses[i].NumBikesAvailableTypes {
-				if available.Mechanical != nil {
-					mechanical = *available.Mechanical
-				}
-
-				if available.Ebike != nil {
-					electric = *available.Ebike
-				}
-			}
-
 			return []any{
 				timestamp,
 				statuses[i].StationID,
-				mechanical,
-				electric,
+				statuses[i].Mechanical(),
+				statuses[i].Electric(),
 			}, nil
 		}),
 	)
 	if err != nil {
-		return fmt.Errorf("db.conn.CopyFrom error")
+		return fmt.Errorf("tx.CopyFrom error: %w", err)
 	}
-	return nil
-}
 
-func (db *Database) GetMinMaxTimestamps(ctx context.Context) (time.Time, time.Time, error) {
-	query := `
-		SELECT MIN(timestamp), MAX(timestamp)
-		FROM statuses
-	`
+	if err := endIngestion(ctx, tx, domain.DatasetStatuses, timestamp); err != nil {
+		return fmt.Errorf("endIngestion error: %w", err)
+	}
 
-	var minTimestamp, maxTimestamp time.Time
-	if err := db.conn.QueryRow(ctx, query).Scan(&minTimestamp, &maxTimestamp); err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("conn.QueryRow.Scan error: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("tx.Commit error: %w", err)
 	}
-	return minTimestamp, maxTimestamp, nil
+	return nil
 }
 
-func (db *Database) FetchStationsStatuses(ctx context.Context, timestamp string) ([]byte, error) {
-	if timestamp == "" {
-		tmstp, err := db.maxVelibTimestamp(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("db.maxVelibTimestamp error: %w", err)
-		}
-		timestamp = tmstp
+func (db *Database) FetchStationsStatuses(ctx context.Context, timestamp time.Time, filter domain.StationsFilter, fn FeatureFunc) error {
+	var conditions where
+	conditions.add("timestamp = %s", timestamp)
+	conditions.intersects("position", filter.BBox)
+	if filter.MinElectric != nil {
+		conditions.add("electric >= %s", *filter.MinElectric)
 	}
 
-	query := `
-		SELECT json_build_object(
-			'type', 'FeatureCollection',
-			'features', json_agg(ST_AsGeoJSON(t.*)::json)
-		)
+	query := fmt.Sprintf(`
+		SELECT ST_AsGeoJSON(t.*)
 		FROM (
 			SELECT stations.id, name, capacity, mechanical, electric, position
 			FROM statuses
 			JOIN stations ON (id = station_id)
-			WHERE timestamp = $1
+			WHERE %s
 		) as t(station_id, name, capacity, mechanical, electric, position)
+	`, conditions.String())
+
+	return db.streamFeatures(ctx, fn, query, conditions.args...)
+}
+
+// GetStationDeltas returns the stations whose bikes changed between the slot
+// before timestamp and timestamp. It reads from the primary as it is called
+// on notifications, which the replica may not have caught up with yet.
+func (db *Database) GetStationDeltas(ctx context.Context, timestamp time.Time) ([]domain.StationDelta, error) {
+	query := `
+		WITH previous AS (
+			SELECT MAX(timestamp) AS timestamp
+			FROM statuses
+			WHERE timestamp < $1 AND timestamp >= $1 - interval '1 hour'
+		)
+		SELECT
+			current.station_id,
+			current.mechanical,
+			current.electric,
+			current.mechanical - COALESCE(before.mechanical, 0),
+			current.electric - COALESCE(before.electric, 0)
+		FROM statuses AS current
+		LEFT JOIN statuses AS before ON (
+			before.station_id = current.station_id
+			AND before.timestamp = (SELECT timestamp FROM previous)
+		)
+		WHERE current.timestamp = $1
+			AND (before.mechanical IS DISTINCT FROM current.mechanical OR before.electric IS DISTINCT FROM current.electric)
 	`
 
-	var data []byte
-	if err := db.conn.QueryRow(ctx, query, timestamp).Scan(&data); err != nil {
-		return nil, fmt.Errorf("db.conn.QueryRow error: %w", err)
+	rows, err := db.conn.Query(ctx, query, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("conn.Query error: %w", classify(err))
 	}
-	return data, nil
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.StationDelta, error) {
+		var delta domain.StationDelta
+		if err := row.Scan(&delta.StationID, &delta.Mechanical, &delta.Electric, &delta.MechanicalChange, &delta.ElectricChange); err != nil {
+			return domain.StationDelta{}, fmt.Errorf("rows.Scan error: %w", err)
+		}
+		return delta, nil
+	})
 }
 
 func (db *Database) GetStations(ctx context.Context, IDs []int) ([]domain.StationInformation, error) {
@@ -137,9 +147,9 @@ func (db *Database) GetStations(ctx context.Context, IDs []int) ([]domain.Statio
 		WHERE id = ANY($1)
 	`
 
-	stationsRows, err := db.conn.Query(ctx, query, IDs)
+	stationsRows, err := db.read.Query(ctx, query, IDs)
 	if err != nil {
-		return nil, fmt.Errorf("conn.Query error: %w", err)
+		return nil, fmt.Errorf("conn.Query error: %w", classify(err))
 	}
 	defer stationsRows.Close()
 
@@ -154,20 +164,27 @@ func (db *Database) GetStations(ctx context.Context, IDs []int) ([]domain.Statio
 
 func (db *Database) GetStationTimeSeries(ctx context.Context, IDs []int) ([]domain.Timeseries, error) {
 	query := `
+		WITH bounds AS (
+			SELECT MIN(timestamp) AS first, MAX(timestamp) AS last
+			FROM statuses
+			WHERE timestamp > NOW() - interval '1 week'
+		), slots AS (
+			SELECT generate_series(first, last, interval '10 minutes') AS timestamp
+			FROM bounds
+		)
 		SELECT
-			timestamp,
-			SUM(mechanical),
-			SUM(electric)
-		FROM statuses
-		WHERE station_id = ANY($1)
-			AND timestamp > NOW() - interval '1 week'
-		GROUP BY timestamp
-		ORDER BY timestamp
+			slots.timestamp,
+			SUM(statuses.mechanical),
+			SUM(statuses.electric)
+		FROM slots
+		LEFT JOIN statuses ON (statuses.timestamp = slots.timestamp AND statuses.station_id = ANY($1))
+		GROUP BY slots.timestamp
+		ORDER BY slots.timestamp
 	`
 
-	timeseriesRows, err := db.conn.Query(ctx, query, IDs)
+	timeseriesRows, err := db.read.Query(ctx, query, IDs)
 	if err != nil {
-		return nil, fmt.Errorf("conn.Query error: %w", err)
+		return nil, fmt.Errorf("conn.Query error: %w", classify(err))
 	}
 	defer timeseriesRows.Close()
 
@@ -180,22 +197,34 @@ func (db *Database) GetStationTimeSeries(ctx context.Context, IDs []int) ([]doma
 	})
 }
 
+// GetStationDistribution averages the stations' bikes by time of day, in
+// local time so that profiles don't shift with daylight saving time.
 func (db *Database) GetStationDistribution(ctx context.Context, IDs []int) ([]domain.DistributionData, error) {
 	query := `
-		SELECT
-			EXTRACT(HOUR FROM timestamp),
-			EXTRACT(MINUTE FROM timestamp),
-			AVG(mechanical),
-			AVG(electric)
-		FROM statuses
-		WHERE station_id = ANY($1)
-		GROUP BY EXTRACT(HOUR FROM timestamp), EXTRACT(MINUTE FROM timestamp)
-		ORDER BY EXTRACT(HOUR FROM timestamp), EXTRACT(MINUTE FROM timestamp)
+		WITH slots AS (
+			SELECT
+				EXTRACT(HOUR FROM slot) AS hour,
+				EXTRACT(MINUTE FROM slot) AS minute
+			FROM generate_series(TIMESTAMP '2000-01-01', TIMESTAMP '2000-01-01 23:50', interval '10 minutes') AS slot
+		), averages AS (
+			SELECT
+				EXTRACT(HOUR FROM timestamp AT TIME ZONE $2) AS hour,
+				EXTRACT(MINUTE FROM timestamp AT TIME ZONE $2) AS minute,
+				AVG(mechanical) AS mechanical,
+				AVG(electric) AS electric
+			FROM statuses
+			WHERE station_id = ANY($1)
+			GROUP BY 1, 2
+		)
+		SELECT slots.hour, slots.minute, averages.mechanical, averages.electric
+		FROM slots
+		LEFT JOIN averages ON (averages.hour = slots.hour AND averages.minute = slots.minute)
+		ORDER BY slots.hour, slots.minute
 	`
 
-	rows, err := db.conn.Query(ctx, query, IDs)
+	rows, err := db.read.Query(ctx, query, IDs, domain.TimeZone)
 	if err != nil {
-		return nil, fmt.Errorf("conn.Query error: %w", err)
+		return nil, fmt.Errorf("conn.Query error: %w", classify(err))
 	}
 	defer rows.Close()
 
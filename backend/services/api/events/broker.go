@@ -0,0 +1,226 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+const (
+	historySize      = 64
+	subscriptionSize = 16
+	retryDelay       = 5 * time.Second
+	deltasTimeout    = 30 * time.Second
+)
+
+// Event is a snapshot published to the subscribers, with the stations that
+// changed when it is one of statuses.
+type Event struct {
+	ID string
+	domain.Snapshot
+	Deltas []domain.StationDelta
+
+	sequence int64
+}
+
+// Subscription receives the events published after it was made. Its channel
+// is closed when the subscriber falls too far behind, it has to subscribe
+// again from the last event it got.
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+}
+
+// Broker listens for the snapshots ingested by the fetcher and publishes them
+// to its subscribers. It keeps the latest events so that subscribers can
+// resume from the last one they got.
+type Broker struct {
+	db *postgres.Database
+
+	// epoch tells apart the event IDs of the successive runs of the api.
+	epoch   int64
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+
+	mu          sync.Mutex
+	sequence    int64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker(db *postgres.Database) *Broker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Broker{
+		db:          db,
+		epoch:       time.Now().Unix(),
+		ctx:         ctx,
+		cancel:      cancel,
+		stopped:     make(chan struct{}),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Start listens until the broker is stopped, reconnecting whenever the
+// connection is lost, so it is ready right away rather than once connected.
+func (b *Broker) Start(ready func()) error {
+	defer close(b.stopped)
+	ready()
+
+	for {
+		err := b.db.Listen(b.ctx, postgres.SnapshotsChannel, b.publish)
+		if b.ctx.Err() != nil {
+			return nil
+		}
+		slog.Error("db.Listen error", slog.String("error", err.Error()))
+
+		select {
+		case <-b.ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// Stop stops listening and ends the subscriptions.
+func (b *Broker) Stop(ctx context.Context) error {
+	b.cancel()
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done is closed when the broker stops.
+func (b *Broker) Done() <-chan struct{} {
+	return b.ctx.Done()
+}
+
+func (b *Broker) publish(payload string) {
+	var snapshot domain.Snapshot
+	if err := json.Unmarshal([]byte(payload), &snapshot); err != nil {
+		slog.Error("json.Unmarshal error", slog.String("error", err.Error()), slog.String("payload", payload))
+		return
+	}
+
+	event := Event{Snapshot: snapshot}
+	if snapshot.Dataset == domain.DatasetStatuses {
+		ctx, cancel := context.WithTimeout(b.ctx, deltasTimeout)
+		deltas, err := b.db.GetStationDeltas(ctx, snapshot.Timestamp)
+		cancel()
+		if err != nil {
+			slog.Error("db.GetStationDeltas error", slog.String("error", err.Error()))
+		}
+		event.Deltas = deltas
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sequence++
+	event.sequence = b.sequence
+	event.ID = fmt.Sprintf("%d-%d", b.epoch, b.sequence)
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			delete(b.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+// sequenceOf returns the sequence of an event ID given by this run.
+func (b *Broker) sequenceOf(id string) (int64, bool) {
+	epoch, sequence, ok := strings.Cut(id, "-")
+	if !ok || epoch != strconv.FormatInt(b.epoch, 10) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(sequence, 10, 64)
+	if err != nil || n > b.sequence {
+		return 0, false
+	}
+
+	// The events following n must still be in the history.
+	if len(b.history) > 0 && n < b.history[0].sequence-1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Subscribe returns a subscription along with the events missed since
+// lastEventID. When lastEventID is too old or comes from a previous run, the
+// latest snapshot of each dataset is returned instead.
+func (b *Broker) Subscribe(ctx context.Context, lastEventID string) (*Subscription, []Event, error) {
+	events := make(chan Event, subscriptionSize)
+	subscription := &Subscription{Events: events, events: events}
+
+	b.mu.Lock()
+	b.subscribers[subscription] = struct{}{}
+
+	if lastEventID == "" {
+		b.mu.Unlock()
+		return subscription, nil, nil
+	}
+
+	if sequence, ok := b.sequenceOf(lastEventID); ok {
+		missed := make([]Event, 0, b.sequence-sequence)
+		for _, event := range b.history {
+			if event.sequence > sequence {
+				missed = append(missed, event)
+			}
+		}
+		b.mu.Unlock()
+		return subscription, missed, nil
+	}
+	b.mu.Unlock()
+
+	latest, err := b.latest(ctx)
+	if err != nil {
+		b.Unsubscribe(subscription)
+		return nil, nil, err
+	}
+	return subscription, latest, nil
+}
+
+func (b *Broker) latest(ctx context.Context) ([]Event, error) {
+	var events []Event
+	for _, dataset := range []string{domain.DatasetStatuses, domain.DatasetFreeFloatingBikes} {
+		slot, err := b.db.LastIngestedSlot(ctx, dataset)
+		if err != nil {
+			return nil, fmt.Errorf("db.LastIngestedSlot error: %w", err)
+		}
+		if slot.IsZero() {
+			continue
+		}
+		events = append(events, Event{Snapshot: domain.Snapshot{Dataset: dataset, Timestamp: slot}})
+	}
+	return events, nil
+}
+
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.events)
+	}
+}
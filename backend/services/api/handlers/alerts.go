@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+const (
+	defaultCooldown = 1 * time.Hour
+	deliveriesLimit = 100
+	secretLength    = 32
+
+	ownerKey = "alerts.owner"
+)
+
+// AlertsConfig restricts the alerts to the callers holding a key, they only
+// see their own alerts.
+type AlertsConfig struct {
+	// Keys are the owners of the alerts by API key, every request is refused
+	// when there are none.
+	Keys map[string]string
+
+	// MaxActive is the number of alerts an owner may have at once.
+	MaxActive int
+
+	// CreateLimit is the number of alerts an owner may create per
+	// CreateWindow.
+	CreateLimit  int
+	CreateWindow time.Duration
+}
+
+type Alerts struct {
+	db      *postgres.Database
+	keys    map[[sha256.Size]byte]string
+	conf    AlertsConfig
+	limiter *windowLimiter
+}
+
+// windowLimiter counts the events of every owner over fixed windows. It is
+// kept by each replica, which is enough to keep a caller from flooding.
+type windowLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]limitWindow
+}
+
+type limitWindow struct {
+	start time.Time
+	count int
+}
+
+// allow records an event of owner and tells how long to wait when it goes
+// over the limit.
+func (l *windowLimiter) allow(owner string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+
+	w, ok := l.windows[owner]
+	if !ok {
+		w = limitWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	l.windows[owner] = w
+	return true, 0
+}
+
+type alertRequest struct {
+	StationIDs []int64              `json:"station_ids" binding:"required,min=1,max=100"`
+	Metric     domain.AlertMetric   `json:"metric" binding:"required,oneof=mechanical electric docks"`
+	Operator   domain.AlertOperator `json:"operator" binding:"required,oneof=gte lte"`
+	Threshold  *int                 `json:"threshold" binding:"required,min=0"`
+	Duration   string               `json:"duration"`
+	Cooldown   string               `json:"cooldown"`
+	WebhookURL string               `json:"webhook_url" binding:"required,url"`
+}
+
+type alertResponse struct {
+	ID         uuid.UUID            `json:"id"`
+	StationIDs []int64              `json:"station_ids"`
+	Metric     domain.AlertMetric   `json:"metric"`
+	Operator   domain.AlertOperator `json:"operator"`
+	Threshold  int                  `json:"threshold"`
+	Duration   string               `json:"duration"`
+	Cooldown   string               `json:"cooldown"`
+	WebhookURL string               `json:"webhook_url"`
+	CreatedAt  time.Time            `json:"created_at"`
+
+	// Secret is only given when the alert is created, it keys the
+	// signature of the webhooks.
+	Secret string `json:"secret,omitempty"`
+}
+
+type deliveryResponse struct {
+	ID            int64           `json:"id"`
+	StationID     int64           `json:"station_id"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	StatusCode    *int            `json:"status_code,omitempty"`
+	Error         *string         `json:"error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type alertURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+func newAlertResponse(alert domain.Alert) alertResponse {
+	return alertResponse{
+		ID:         alert.ID,
+		StationIDs: alert.StationIDs,
+		Metric:     alert.Metric,
+		Operator:   alert.Operator,
+		Threshold:  alert.Threshold,
+		Duration:   alert.Duration.String(),
+		Cooldown:   alert.Cooldown.String(),
+		WebhookURL: alert.WebhookURL,
+		CreatedAt:  alert.CreatedAt,
+	}
+}
+
+func parseDuration(name, raw string, fallback time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, raw, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must not be negative", name, raw)
+	}
+	return d, nil
+}
+
+func (r alertRequest) alert() (domain.Alert, error) {
+	webhook, err := url.Parse(r.WebhookURL)
+	if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+		return domain.Alert{}, fmt.Errorf("invalid webhook_url %q: expected an http or https URL", r.WebhookURL)
+	}
+
+	duration, err := parseDuration("duration", r.Duration, 0)
+	if err != nil {
+		return domain.Alert{}, err
+	}
+	cooldown, err := parseDuration("cooldown", r.Cooldown, defaultCooldown)
+	if err != nil {
+		return domain.Alert{}, err
+	}
+
+	return domain.Alert{
+		ID:         uuid.New(),
+		StationIDs: r.StationIDs,
+		Metric:     r.Metric,
+		Operator:   r.Operator,
+		Threshold:  *r.Threshold,
+		Duration:   duration,
+		Cooldown:   cooldown,
+		WebhookURL: webhook.String(),
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// Authenticate resolves the owner of the bearer key of the request. The keys
+// are compared through their hashes so that the lookup doesn't leak them.
+func (a *Alerts) Authenticate(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if ok {
+		var owner string
+		sum := sha256.Sum256([]byte(token))
+		for key, candidate := range a.keys {
+			if subtle.ConstantTimeCompare(key[:], sum[:]) == 1 {
+				owner = candidate
+			}
+		}
+		if owner != "" {
+			c.Set(ownerKey, owner)
+			c.Next()
+			return
+		}
+	}
+
+	c.Header("WWW-Authenticate", `Bearer realm="alerts"`)
+	_ = c.Error(domain.ErrUnauthorized)
+	c.Abort()
+}
+
+func owner(c *gin.Context) string {
+	return c.GetString(ownerKey)
+}
+
+func bindAlertID(c *gin.Context) (uuid.UUID, bool) {
+	var uri alertURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return uuid.Nil, false
+	}
+	return uuid.MustParse(uri.ID), true
+}
+
+func (a *Alerts) CreateAlert(c *gin.Context) {
+	var request alertRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	alert, err := request.alert()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	alert.Owner = owner(c)
+
+	if ok, wait := a.limiter.allow(alert.Owner, time.Now()); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		_ = c.Error(fmt.Errorf("%w: at most %d alerts per %s", domain.ErrRateLimited, a.conf.CreateLimit, a.conf.CreateWindow))
+		return
+	}
+
+	secret := make([]byte, secretLength)
+	_, _ = rand.Read(secret)
+	alert.Secret = hex.EncodeToString(secret)
+
+	if err := a.db.InsertAlert(c.Request.Context(), alert, a.conf.MaxActive); err != nil {
+		_ = c.Error(fmt.Errorf("db.InsertAlert error: %w", err))
+		return
+	}
+
+	response := newAlertResponse(alert)
+	response.Secret = alert.Secret
+	c.Header("Location", fmt.Sprintf("/api/v1/alerts/%s", alert.ID))
+	c.JSON(http.StatusCreated, response)
+}
+
+func (a *Alerts) GetAlert(c *gin.Context) {
+	id, ok := bindAlertID(c)
+	if !ok {
+		return
+	}
+
+	alert, err := a.db.GetAlert(c.Request.Context(), owner(c), id)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetAlert error: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, newAlertResponse(alert))
+}
+
+func (a *Alerts) DeleteAlert(c *gin.Context) {
+	id, ok := bindAlertID(c)
+	if !ok {
+		return
+	}
+
+	if err := a.db.DeleteAlert(c.Request.Context(), owner(c), id); err != nil {
+		_ = c.Error(fmt.Errorf("db.DeleteAlert error: %w", err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetAlertDeliveries returns the delivery log of an alert, newest first.
+func (a *Alerts) GetAlertDeliveries(c *gin.Context) {
+	id, ok := bindAlertID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := a.db.GetAlert(c.Request.Context(), owner(c), id); err != nil {
+		_ = c.Error(fmt.Errorf("db.GetAlert error: %w", err))
+		return
+	}
+
+	deliveries, err := a.db.GetAlertDeliveries(c.Request.Context(), id, deliveriesLimit)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetAlertDeliveries error: %w", err))
+		return
+	}
+
+	response := make([]deliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		current := deliveryResponse{
+			ID:          delivery.ID,
+			StationID:   delivery.StationID,
+			Timestamp:   delivery.Timestamp,
+			Payload:     delivery.Payload,
+			Status:      delivery.Status,
+			Attempts:    delivery.Attempts,
+			StatusCode:  delivery.StatusCode,
+			Error:       delivery.Error,
+			CreatedAt:   delivery.CreatedAt,
+			DeliveredAt: delivery.DeliveredAt,
+		}
+		if delivery.Status == domain.DeliveryPending {
+			current.NextAttemptAt = &delivery.NextAttemptAt
+		}
+		response = append(response, current)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func NewAlerts(db *postgres.Database, conf AlertsConfig) *Alerts {
+	keys := make(map[[sha256.Size]byte]string, len(conf.Keys))
+	for key, owner := range conf.Keys {
+		keys[sha256.Sum256([]byte(key))] = owner
+	}
+
+	return &Alerts{
+		db:   db,
+		keys: keys,
+		conf: conf,
+		limiter: &windowLimiter{
+			limit:   conf.CreateLimit,
+			window:  conf.CreateWindow,
+			windows: make(map[string]limitWindow),
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowLimiter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	limiter := &windowLimiter{
+		limit:   2,
+		window:  time.Minute,
+		windows: make(map[string]limitWindow),
+	}
+
+	tests := []struct {
+		name    string
+		owner   string
+		elapsed time.Duration
+		allowed bool
+		wait    time.Duration
+	}{
+		{name: "first event", owner: "a", elapsed: 0, allowed: true},
+		{name: "within the limit", owner: "a", elapsed: 10 * time.Second, allowed: true},
+		{name: "over the limit", owner: "a", elapsed: 20 * time.Second, allowed: false, wait: 40 * time.Second},
+		{name: "other owner", owner: "b", elapsed: 20 * time.Second, allowed: true},
+		{name: "still over the limit", owner: "a", elapsed: 59 * time.Second, allowed: false, wait: time.Second},
+		{name: "next window", owner: "a", elapsed: time.Minute, allowed: true},
+	}
+
+	// The events share the limiter, so they run in order.
+	for _, test := range tests {
+		allowed, wait := limiter.allow(test.owner, start.Add(test.elapsed))
+		if allowed != test.allowed || wait != test.wait {
+			t.Errorf("%s: allow = %v, %s, want %v, %s", test.name, allowed, wait, test.allowed, test.wait)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+type Areas struct {
+	db *postgres.Database
+}
+
+type rankingsQuery struct {
+	snapshotQuery
+	Metric domain.RankingMetric `form:"metric,default=bikes_per_station" binding:"oneof=bikes_per_station empty_share electric_share"`
+	Order  string               `form:"order,default=desc" binding:"oneof=asc desc"`
+	From   string               `form:"from"`
+	To     string               `form:"to"`
+}
+
+func (a *Areas) getTimeSeries(c *gin.Context, area string) {
+	location, ok := bindLocationQuery(c)
+	if !ok {
+		return
+	}
+
+	IDs, err := a.db.GetAreaStations(c.Request.Context(), area, c.Param("name"))
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetAreaStations error: %w", err))
+		return
+	}
+
+	timeseries, err := a.db.GetStationTimeSeries(c.Request.Context(), IDs)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetStationTimeSeries error: %w", err))
+		return
+	}
+
+	for i := range timeseries {
+		timeseries[i].Date = timeseries[i].Date.In(location)
+	}
+	c.JSON(http.StatusOK, timeseries)
+}
+
+// GetBoroughTimeSeries returns the bikes docked in the stations of a borough,
+// found by name or label.
+func (a *Areas) GetBoroughTimeSeries(c *gin.Context) {
+	a.getTimeSeries(c, domain.AreaBoroughs)
+}
+
+// GetDistrictTimeSeries returns the bikes docked in the stations of an
+// administrative district.
+func (a *Areas) GetDistrictTimeSeries(c *gin.Context) {
+	a.getTimeSeries(c, domain.AreaDistricts)
+}
+
+// GetRankings ranks the boroughs or the districts over a period given by from
+// and to, or at a single slot given like snapshots are.
+func (a *Areas) GetRankings(c *gin.Context) {
+	area := c.Param("area")
+
+	var query rankingsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	var from, to time.Time
+	if query.From == "" && query.To == "" {
+		requested := time.Time{}
+		if query.Timestamp != "" {
+			if requested, err = parseTimestamp(query.Timestamp, location); err != nil {
+				_ = c.Error(err)
+				return
+			}
+		}
+
+		if to, err = a.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetStatuses, requested, query.Snap); err != nil {
+			_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
+			return
+		}
+		setSnapshotHeaders(c, requested, to)
+		from = to
+	} else {
+		if query.Timestamp != "" {
+			_ = c.Error(errors.New("timestamp can't be combined with from and to")).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		_, last, err := a.db.TimestampBounds(c.Request.Context(), domain.DatasetStatuses)
+		if err != nil {
+			_ = c.Error(fmt.Errorf("db.TimestampBounds error: %w", err))
+			return
+		}
+
+		var ok bool
+		if from, to, ok = timeRange(c, query.From, query.To, location, last); !ok {
+			return
+		}
+	}
+
+	rankings, err := a.db.GetRankings(c.Request.Context(), area, query.Metric, query.Order == "asc", from, to)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetRankings error: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.Rankings{
+		Area:     area,
+		Metric:   query.Metric,
+		From:     from.In(location),
+		To:       to.In(location),
+		Rankings: rankings,
+	})
+}
+
+func NewAreas(db *postgres.Database) *Areas {
+	return &Areas{
+		db: db,
+	}
+}
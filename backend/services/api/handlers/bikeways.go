@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"log/slog"
-	"net/http"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
 
@@ -14,14 +14,26 @@ type BikeLanes struct {
 }
 
 func (b *BikeLanes) FetchBikeLanes(c *gin.Context) {
-	data, err := b.db.FetchBikeLanes(c.Request.Context())
-	if err != nil {
-		slog.Error("b.db.FetchBikeLanes error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+	var query bikeLanesQuery
+	bbox, ok := bindFeaturesQuery(c, &query)
+	if !ok {
 		return
 	}
+
+	filter := domain.BikeLanesFilter{
+		BBox:           bbox,
+		Amenagement:    query.Amenagement,
+		Arrondissement: query.Arrondissement,
+		Bidirectional:  query.Bidirectional,
+	}
+
 	c.Header("Cache-Control", "max-age=86400, immutable")
-	c.Data(http.StatusOK, "application/json", data)
+	err := writeFeatures(c, func(fn postgres.FeatureFunc) error {
+		return b.db.FetchBikeLanes(c.Request.Context(), filter, fn)
+	})
+	if err != nil {
+		failStream(c, fmt.Errorf("db.FetchBikeLanes error: %w", err))
+	}
 }
 
 func NewBikeLanes(db *postgres.Database) *BikeLanes {
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/services/api/events"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	reconnectDelay    = 5 * time.Second
+)
+
+type Events struct {
+	broker *events.Broker
+}
+
+type eventsQuery struct {
+	locationQuery
+	Deltas bool `form:"deltas"`
+}
+
+type snapshotEvent struct {
+	Dataset   string                `json:"dataset"`
+	Timestamp time.Time             `json:"timestamp"`
+	Deltas    []domain.StationDelta `json:"deltas,omitempty"`
+}
+
+// StreamEvents pushes a snapshot event every time the fetcher ingests a slot,
+// resuming after the Last-Event-ID of a reconnecting client.
+func (e *Events) StreamEvents(c *gin.Context) {
+	var query eventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	subscription, missed, err := e.broker.Subscribe(c.Request.Context(), c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		_ = c.Error(fmt.Errorf("broker.Subscribe error: %w", err))
+		return
+	}
+	defer e.broker.Unsubscribe(subscription)
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprintf(c.Writer, "retry: %d\n\n", reconnectDelay.Milliseconds())
+
+	write := func(event events.Event) {
+		data := snapshotEvent{
+			Dataset:   event.Dataset,
+			Timestamp: event.Timestamp.In(location),
+		}
+		if query.Deltas {
+			data.Deltas = event.Deltas
+		}
+		c.Render(-1, sse.Event{Id: event.ID, Event: "snapshot", Data: data})
+	}
+
+	for _, event := range missed {
+		write(event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-e.broker.Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			write(event)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func NewEvents(broker *events.Broker) *Events {
+	return &Events{
+		broker: broker,
+	}
+}
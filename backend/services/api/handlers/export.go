@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+type Export struct {
+	db *postgres.Database
+}
+
+type exportQuery struct {
+	locationQuery
+	From    string `form:"from" binding:"required"`
+	To      string `form:"to" binding:"required"`
+	IDs     []int  `form:"ids[]"`
+	Borough string `form:"borough"`
+	Format  string `form:"format,default=csv" binding:"oneof=csv parquet"`
+}
+
+// recordWriter encodes the exported statuses, Close writes whatever the
+// format needs at the end of the file.
+type recordWriter interface {
+	Write(records []domain.StatusRecord) error
+	Close() error
+}
+
+var csvHeader = []string{"timestamp", "station_id", "name", "capacity", "latitude", "longitude", "mechanical", "electric"}
+
+type csvWriter struct {
+	w        *csv.Writer
+	location *time.Location
+}
+
+func newCSVWriter(w io.Writer, location *time.Location) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, fmt.Errorf("csv.Write error: %w", err)
+	}
+	return &csvWriter{w: writer, location: location}, nil
+}
+
+func (c *csvWriter) Write(records []domain.StatusRecord) error {
+	for _, record := range records {
+		err := c.w.Write([]string{
+			record.Timestamp.In(c.location).Format(time.RFC3339),
+			strconv.FormatInt(record.StationID, 10),
+			record.Name,
+			strconv.Itoa(record.Capacity),
+			strconv.FormatFloat(record.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(record.Longitude, 'f', -1, 64),
+			strconv.Itoa(record.Mechanical),
+			strconv.Itoa(record.Electric),
+		})
+		if err != nil {
+			return fmt.Errorf("csv.Write error: %w", err)
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type parquetRow struct {
+	Timestamp  time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	StationID  int64     `parquet:"station_id"`
+	Name       string    `parquet:"name,dict"`
+	Capacity   int32     `parquet:"capacity"`
+	Latitude   float64   `parquet:"latitude"`
+	Longitude  float64   `parquet:"longitude"`
+	Mechanical int32     `parquet:"mechanical"`
+	Electric   int32     `parquet:"electric"`
+}
+
+type parquetWriter struct {
+	w    *parquet.GenericWriter[parquetRow]
+	rows []parquetRow
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w: parquet.NewGenericWriter[parquetRow](w, parquet.MaxRowsPerRowGroup(100_000)),
+	}
+}
+
+func (p *parquetWriter) Write(records []domain.StatusRecord) error {
+	p.rows = p.rows[:0]
+	for _, record := range records {
+		p.rows = append(p.rows, parquetRow{
+			Timestamp:  record.Timestamp.UTC(),
+			StationID:  record.StationID,
+			Name:       record.Name,
+			Capacity:   int32(record.Capacity),
+			Latitude:   record.Latitude,
+			Longitude:  record.Longitude,
+			Mechanical: int32(record.Mechanical),
+			Electric:   int32(record.Electric),
+		})
+	}
+
+	if _, err := p.w.Write(p.rows); err != nil {
+		return fmt.Errorf("parquet.Write error: %w", err)
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
+
+// ExportStatuses streams the statuses of a time range, optionally restricted
+// to some stations or to a borough, as CSV or Parquet.
+func (e *Export) ExportStatuses(c *gin.Context) {
+	var query exportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	filter := domain.StatusesFilter{
+		StationIDs: query.IDs,
+		Borough:    query.Borough,
+	}
+	if filter.From, err = parseTimestamp(query.From, location); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if filter.To, err = parseTimestamp(query.To, location); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if filter.To.Before(filter.From) {
+		_ = c.Error(errors.New("to can't be before from")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	var writer recordWriter
+	switch query.Format {
+	case "parquet":
+		c.Header("Content-Type", "application/vnd.apache.parquet")
+		writer = newParquetWriter(c.Writer)
+	default:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		if writer, err = newCSVWriter(c.Writer, location); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statuses.%s"`, query.Format))
+	c.Status(http.StatusOK)
+
+	if err := e.db.ExportStatuses(c.Request.Context(), filter, writer.Write); err != nil {
+		failStream(c, fmt.Errorf("db.ExportStatuses error: %w", err))
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		failStream(c, fmt.Errorf("writer.Close error: %w", err))
+	}
+}
+
+func NewExport(db *postgres.Database) *Export {
+	return &Export{
+		db: db,
+	}
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"log/slog"
-	"net/http"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/oupo1337/velibs/backend/common/geojson"
+	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
 
@@ -14,19 +15,37 @@ type FreeFloatingBikes struct {
 }
 
 func (f *FreeFloatingBikes) GetFreeFloatingBikes(c *gin.Context) {
-	timestamp := c.Query("timestamp")
+	query, requested, location, ok := bindSnapshotQuery(c)
+	if !ok {
+		return
+	}
+
+	var filters freeFloatingBikesQuery
+	bbox, ok := bindFeaturesQuery(c, &filters)
+	if !ok {
+		return
+	}
 
-	data, err := f.db.FetchFreeFloatingBikes(c.Request.Context(), timestamp)
+	timestamp, err := f.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetFreeFloatingBikes, requested, query.Snap)
 	if err != nil {
-		slog.Error("s.db.FetchFreeFloatingBikes error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
 		return
 	}
 
-	if timestamp != "" {
-		c.Header("Cache-Control", "max-age=86400, immutable")
+	filter := domain.FreeFloatingBikesFilter{
+		BBox:        bbox,
+		IsDisabled:  filters.IsDisabled,
+		MinRange:    filters.MinRange,
+		VehicleType: filters.VehicleType,
+	}
+
+	setSnapshotHeaders(c, requested, timestamp)
+	err = writeFeatures(c, func(fn postgres.FeatureFunc) error {
+		return f.db.FetchFreeFloatingBikes(c.Request.Context(), timestamp, filter, fn)
+	}, geojson.WithMember("timestamp", timestamp.In(location)))
+	if err != nil {
+		failStream(c, fmt.Errorf("db.FetchFreeFloatingBikes error: %w", err))
 	}
-	c.Data(http.StatusOK, "application/json", data)
 }
 
 func NewFreeFloatingBikes(db *postgres.Database) *FreeFloatingBikes {
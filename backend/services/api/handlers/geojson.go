@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/common/geojson"
+	"github.com/oupo1337/velibs/backend/common/middleware"
+	"github.com/oupo1337/velibs/backend/common/topojson"
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+// bboxQuery restricts a collection to the features intersecting a
+// minLon,minLat,maxLon,maxLat bounding box.
+type bboxQuery struct {
+	BBox string `form:"bbox"`
+}
+
+func (q bboxQuery) boundingBox() (*domain.BoundingBox, error) {
+	if q.BBox == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(q.BBox, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid bbox %q: expected minLon,minLat,maxLon,maxLat", q.BBox)
+	}
+
+	var coordinates [4]float64
+	for i := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox %q: %w", q.BBox, err)
+		}
+		coordinates[i] = value
+	}
+
+	bbox := domain.BoundingBox{
+		MinLon: coordinates[0],
+		MinLat: coordinates[1],
+		MaxLon: coordinates[2],
+		MaxLat: coordinates[3],
+	}
+	if !bbox.Valid() {
+		return nil, fmt.Errorf("invalid bbox %q: out of bounds or inverted corners", q.BBox)
+	}
+	return &bbox, nil
+}
+
+// boundariesQuery reduces the boroughs, districts and communes shapes, and
+// tells whether to send them as TopoJSON so that shared borders are sent once.
+type boundariesQuery struct {
+	bboxQuery
+	Simplify  float64 `form:"simplify" binding:"min=0"`
+	Precision int     `form:"precision,default=9" binding:"min=0,max=15"`
+	Format    string  `form:"format,default=geojson" binding:"oneof=geojson topojson"`
+}
+
+func (q boundariesQuery) filter(bbox *domain.BoundingBox) domain.BoundariesFilter {
+	return domain.BoundariesFilter{
+		BBox:      bbox,
+		Precision: q.Precision,
+	}
+}
+
+type stationsQuery struct {
+	bboxQuery
+	MinElectric *int `form:"min_electric" binding:"omitempty,min=0"`
+}
+
+type bikeLanesQuery struct {
+	bboxQuery
+	Amenagement    string `form:"amenagement"`
+	Arrondissement string `form:"arrondissement"`
+	Bidirectional  *bool  `form:"bidirectional"`
+}
+
+type freeFloatingBikesQuery struct {
+	bboxQuery
+	IsDisabled  *bool  `form:"is_disabled"`
+	MinRange    *int   `form:"min_range" binding:"omitempty,min=0"`
+	VehicleType string `form:"vehicle_type"`
+}
+
+// bindFeaturesQuery binds the filters of a GeoJSON route into query and
+// returns its bounding box, nil when none was given.
+func bindFeaturesQuery(c *gin.Context, query interface {
+	boundingBox() (*domain.BoundingBox, error)
+}) (*domain.BoundingBox, bool) {
+	if err := c.ShouldBindQuery(query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return nil, false
+	}
+
+	bbox, err := query.boundingBox()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return nil, false
+	}
+	return bbox, true
+}
+
+// failStream reports err, and aborts the connection once the response is
+// started so that neither the client nor a cache takes the truncated body for
+// a complete one.
+func failStream(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if c.Writer.Written() {
+		slog.ErrorContext(c.Request.Context(), "stream aborted", slog.String("error", err.Error()))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// writeFeatures streams the features produced by fetch as a FeatureCollection,
+// or as a GeoJSON text sequence when the client asks for one.
+func writeFeatures(c *gin.Context, fetch func(fn postgres.FeatureFunc) error, options ...geojson.Option) error {
+	contentType := c.NegotiateFormat(geojson.ContentType, gin.MIMEJSON, geojson.SeqContentType)
+	seq := contentType == geojson.SeqContentType
+	if !seq {
+		contentType = geojson.ContentType
+	}
+
+	middleware.AddVary(c.Writer.Header(), "Accept")
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	writer := geojson.NewWriter(c.Writer, append(options, geojson.WithSequence(seq))...)
+	if err := fetch(writer.WriteFeature); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// writeBoundaries streams the features produced by fetch as GeoJSON, or
+// gathers them in a TopoJSON topology made of a single object called name.
+// Both carry members next to the features.
+//
+// Simplified shapes always go through the topology, which simplifies the
+// borders once cut into arcs so that neighbours keep sharing them.
+func writeBoundaries(c *gin.Context, query boundariesQuery, name string, members map[string]any, fetch func(fn postgres.FeatureFunc) error) error {
+	featuresOptions := make([]geojson.Option, 0, len(members))
+	topologyOptions := []topojson.Option{topojson.WithSimplify(query.Simplify)}
+	for key, value := range members {
+		featuresOptions = append(featuresOptions, geojson.WithMember(key, value))
+		topologyOptions = append(topologyOptions, topojson.WithMember(key, value))
+	}
+
+	if query.Format != "topojson" && query.Simplify <= 0 {
+		return writeFeatures(c, fetch, featuresOptions...)
+	}
+
+	topology := topojson.New(name, topologyOptions...)
+	if err := fetch(topology.WriteFeature); err != nil {
+		return err
+	}
+
+	if query.Format != "topojson" {
+		features, err := topology.Features()
+		if err != nil {
+			return fmt.Errorf("topology.Features error: %w", err)
+		}
+
+		return writeFeatures(c, func(fn postgres.FeatureFunc) error {
+			for _, feature := range features {
+				data, err := feature.MarshalJSON()
+				if err != nil {
+					return fmt.Errorf("feature.MarshalJSON error: %w", err)
+				}
+				if err := fn(data); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, featuresOptions...)
+	}
+
+	c.Header("Content-Type", topojson.ContentType)
+	c.Status(http.StatusOK)
+	return topology.Encode(c.Writer)
+}
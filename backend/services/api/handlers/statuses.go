@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"fmt"
-	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/oupo1337/velibs/backend/common/geojson"
+	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
 
@@ -19,17 +20,21 @@ type idsQuery struct {
 	IDs []int `form:"ids[]" binding:"required"`
 }
 
+type timeseriesQuery struct {
+	idsQuery
+	locationQuery
+}
+
 func (s *Statuses) GetStations(c *gin.Context) {
 	var query idsQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		c.Status(http.StatusBadRequest)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	stations, err := s.db.GetStations(c.Request.Context(), query.IDs)
 	if err != nil {
 		_ = c.Error(fmt.Errorf("db.GetStations error: %w", err))
-		c.Status(http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, stations)
@@ -38,32 +43,40 @@ func (s *Statuses) GetStations(c *gin.Context) {
 func (s *Statuses) GetStationDistribution(c *gin.Context) {
 	var query idsQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		c.Status(http.StatusBadRequest)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	distribution, err := s.db.GetStationDistribution(c.Request.Context(), query.IDs)
 	if err != nil {
 		_ = c.Error(fmt.Errorf("db.GetStationDistribution error: %w", err))
-		c.Status(http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, distribution)
 }
 
 func (s *Statuses) GetStationTimeSeries(c *gin.Context) {
-	var query idsQuery
+	var query timeseriesQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		c.Status(http.StatusBadRequest)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	timeseries, err := s.db.GetStationTimeSeries(c.Request.Context(), query.IDs)
 	if err != nil {
 		_ = c.Error(fmt.Errorf("db.GetStationTimeSeries error: %w", err))
-		c.Status(http.StatusInternalServerError)
 		return
 	}
+
+	for i := range timeseries {
+		timeseries[i].Date = timeseries[i].Date.In(location)
+	}
 	c.JSON(http.StatusOK, timeseries)
 }
 
@@ -73,64 +86,160 @@ type minMaxTimestampResponse struct {
 }
 
 func (s *Statuses) GetMinMaxTimestamps(c *gin.Context) {
-	minTimestamp, maxTimestamp, err := s.db.GetMinMaxTimestamps(c.Request.Context())
+	location, ok := bindLocationQuery(c)
+	if !ok {
+		return
+	}
+
+	minTimestamp, maxTimestamp, err := s.db.TimestampBounds(c.Request.Context(), domain.DatasetStatuses)
 	if err != nil {
-		slog.Error("db.GetMinMaxTimestamps error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("db.TimestampBounds error: %w", err))
 		return
 	}
 	c.JSON(http.StatusOK, minMaxTimestampResponse{
-		Min: minTimestamp,
-		Max: maxTimestamp,
+		Min: minTimestamp.In(location),
+		Max: maxTimestamp.In(location),
 	})
 }
 
+// boundariesMembers gives the slot of the boundaries, and the bikes of the
+// stations outside of them so that they don't go missing from the totals.
+func (s *Statuses) boundariesMembers(c *gin.Context, area string, timestamp time.Time, bbox *domain.BoundingBox, location *time.Location) (map[string]any, error) {
+	outside, err := s.db.GetOutsideStatuses(c.Request.Context(), area, timestamp, bbox)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetOutsideStatuses error: %w", err)
+	}
+
+	return map[string]any{
+		"timestamp":     timestamp.In(location),
+		"outside_paris": outside,
+	}, nil
+}
+
 func (s *Statuses) GetAdministrativeDistrictsStatuses(c *gin.Context) {
-	timestamp := c.Query("timestamp")
+	query, requested, location, ok := bindSnapshotQuery(c)
+	if !ok {
+		return
+	}
+
+	var filters boundariesQuery
+	bbox, ok := bindFeaturesQuery(c, &filters)
+	if !ok {
+		return
+	}
 
-	data, err := s.db.GetAdministrativeDistricts(c.Request.Context(), timestamp)
+	timestamp, err := s.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetStatuses, requested, query.Snap)
 	if err != nil {
-		slog.Error("s.db.GetAdministrativeDistricts error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
 		return
 	}
 
-	if timestamp != "" {
-		c.Header("Cache-Control", "max-age=86400, immutable")
+	members, err := s.boundariesMembers(c, domain.AreaDistricts, timestamp, bbox, location)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("s.boundariesMembers error: %w", err))
+		return
+	}
+
+	setSnapshotHeaders(c, requested, timestamp)
+	err = writeBoundaries(c, filters, "districts", members, func(fn postgres.FeatureFunc) error {
+		return s.db.GetAdministrativeDistricts(c.Request.Context(), timestamp, filters.filter(bbox), fn)
+	})
+	if err != nil {
+		failStream(c, fmt.Errorf("db.GetAdministrativeDistricts error: %w", err))
 	}
-	c.Data(http.StatusOK, "application/json", data)
 }
 
 func (s *Statuses) GetBoroughs(c *gin.Context) {
-	timestamp := c.Query("timestamp")
+	query, requested, location, ok := bindSnapshotQuery(c)
+	if !ok {
+		return
+	}
+
+	var filters boundariesQuery
+	bbox, ok := bindFeaturesQuery(c, &filters)
+	if !ok {
+		return
+	}
+
+	timestamp, err := s.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetStatuses, requested, query.Snap)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
+		return
+	}
 
-	data, err := s.db.GetBoroughs(c.Request.Context(), timestamp)
+	members, err := s.boundariesMembers(c, domain.AreaBoroughs, timestamp, bbox, location)
 	if err != nil {
-		slog.Error("s.db.GetBoroughs error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("s.boundariesMembers error: %w", err))
 		return
 	}
 
-	if timestamp != "" {
-		c.Header("Cache-Control", "max-age=86400, immutable")
+	setSnapshotHeaders(c, requested, timestamp)
+	err = writeBoundaries(c, filters, "boroughs", members, func(fn postgres.FeatureFunc) error {
+		return s.db.GetBoroughs(c.Request.Context(), timestamp, filters.filter(bbox), fn)
+	})
+	if err != nil {
+		failStream(c, fmt.Errorf("db.GetBoroughs error: %w", err))
+	}
+}
+
+func (s *Statuses) GetCommunes(c *gin.Context) {
+	query, requested, location, ok := bindSnapshotQuery(c)
+	if !ok {
+		return
+	}
+
+	var filters boundariesQuery
+	bbox, ok := bindFeaturesQuery(c, &filters)
+	if !ok {
+		return
+	}
+
+	timestamp, err := s.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetStatuses, requested, query.Snap)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
+		return
+	}
+
+	setSnapshotHeaders(c, requested, timestamp)
+	members := map[string]any{"timestamp": timestamp.In(location)}
+	err = writeBoundaries(c, filters, "communes", members, func(fn postgres.FeatureFunc) error {
+		return s.db.GetCommunes(c.Request.Context(), timestamp, filters.filter(bbox), fn)
+	})
+	if err != nil {
+		failStream(c, fmt.Errorf("db.GetCommunes error: %w", err))
 	}
-	c.Data(http.StatusOK, "application/json", data)
 }
 
 func (s *Statuses) GetStationsStatuses(c *gin.Context) {
-	timestamp := c.Query("timestamp")
+	query, requested, location, ok := bindSnapshotQuery(c)
+	if !ok {
+		return
+	}
+
+	var filters stationsQuery
+	bbox, ok := bindFeaturesQuery(c, &filters)
+	if !ok {
+		return
+	}
 
-	data, err := s.db.FetchStationsStatuses(c.Request.Context(), timestamp)
+	timestamp, err := s.db.SnapshotTimestamp(c.Request.Context(), domain.DatasetStatuses, requested, query.Snap)
 	if err != nil {
-		slog.Error("s.db.FetchTimestamp error", slog.String("error", err.Error()))
-		c.Status(http.StatusInternalServerError)
+		_ = c.Error(fmt.Errorf("db.SnapshotTimestamp error: %w", err))
 		return
 	}
 
-	if timestamp != "" {
-		c.Header("Cache-Control", "max-age=86400, immutable")
+	filter := domain.StationsFilter{
+		BBox:        bbox,
+		MinElectric: filters.MinElectric,
+	}
+
+	setSnapshotHeaders(c, requested, timestamp)
+	err = writeFeatures(c, func(fn postgres.FeatureFunc) error {
+		return s.db.FetchStationsStatuses(c.Request.Context(), timestamp, filter, fn)
+	}, geojson.WithMember("timestamp", timestamp.In(location)))
+	if err != nil {
+		failStream(c, fmt.Errorf("db.FetchStationsStatuses error: %w", err))
 	}
-	c.Data(http.StatusOK, "application/json", data)
 }
 
 func NewStatuses(db *postgres.Database) *Statuses {
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+const (
+	defaultTimelineRange = 24 * time.Hour
+	maxTimelineRange     = 31 * 24 * time.Hour
+)
+
+type Timeline struct {
+	db *postgres.Database
+}
+
+type timelineQuery struct {
+	locationQuery
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// GetTimeline returns the range of a dataset and its slots with data, by
+// default over the last day available.
+func (t *Timeline) GetTimeline(c *gin.Context) {
+	dataset := c.Param("dataset")
+
+	var query timelineQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	first, last, err := t.db.TimestampBounds(c.Request.Context(), dataset)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.TimestampBounds error: %w", err))
+		return
+	}
+
+	from, to, ok := timeRange(c, query.From, query.To, location, last)
+	if !ok {
+		return
+	}
+
+	slots, err := t.db.GetSlots(c.Request.Context(), dataset, from, to)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetSlots error: %w", err))
+		return
+	}
+
+	gaps, err := t.db.GetGaps(c.Request.Context(), dataset, from, to)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("db.GetGaps error: %w", err))
+		return
+	}
+
+	for i := range slots {
+		slots[i] = slots[i].In(location)
+	}
+	for i := range gaps {
+		gaps[i].Start = gaps[i].Start.In(location)
+		gaps[i].End = gaps[i].End.In(location)
+	}
+
+	c.JSON(http.StatusOK, domain.Timeline{
+		Dataset: dataset,
+		Min:     first.In(location),
+		Max:     last.In(location),
+		From:    from.In(location),
+		To:      to.In(location),
+		Slots:   slots,
+		Gaps:    gaps,
+	})
+}
+
+// timeRange reads the from and to of a range, by default the day before last,
+// and reports whether they were valid.
+func timeRange(c *gin.Context, rawFrom, rawTo string, location *time.Location, last time.Time) (time.Time, time.Time, bool) {
+	var err error
+	to := last
+	if rawTo != "" {
+		if to, err = parseTimestamp(rawTo, location); err != nil {
+			_ = c.Error(err)
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	from := to.Add(-defaultTimelineRange)
+	if rawFrom != "" {
+		if from, err = parseTimestamp(rawFrom, location); err != nil {
+			_ = c.Error(err)
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	if to.Before(from) || to.Sub(from) > maxTimelineRange {
+		_ = c.Error(fmt.Errorf("range must be positive and at most %s", maxTimelineRange)).SetType(gin.ErrorTypeBind)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+func NewTimeline(db *postgres.Database) *Timeline {
+	return &Timeline{
+		db: db,
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// localLayouts are the accepted formats without offset, they are read in the
+// time zone given by the tz parameter.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// locationQuery is the time zone the timestamps of the response are given in,
+// and the one timestamps without offset are read in.
+type locationQuery struct {
+	TZ string `form:"tz,default=UTC"`
+}
+
+func (q locationQuery) location() (*time.Location, error) {
+	location, err := time.LoadLocation(q.TZ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz %q: %w", q.TZ, err)
+	}
+	return location, nil
+}
+
+func bindLocationQuery(c *gin.Context) (*time.Location, bool) {
+	var query locationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return nil, false
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return nil, false
+	}
+	return location, true
+}
+
+type snapshotQuery struct {
+	locationQuery
+	Timestamp string      `form:"timestamp"`
+	Snap      domain.Snap `form:"snap,default=nearest" binding:"oneof=before after nearest"`
+}
+
+func parseTimestamp(raw string, location *time.Location) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	// A "+" offset that wasn't escaped in the query string is decoded as a space.
+	for _, candidate := range []string{raw, strings.Replace(raw, " ", "+", 1)} {
+		if t, err := time.Parse(time.RFC3339Nano, candidate); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, raw, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", domain.ErrInvalidTimestamp, raw)
+}
+
+// bindSnapshotQuery returns the requested timestamp, zero when the latest one
+// is requested, and the location of the response.
+func bindSnapshotQuery(c *gin.Context) (snapshotQuery, time.Time, *time.Location, bool) {
+	var query snapshotQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return snapshotQuery{}, time.Time{}, nil, false
+	}
+
+	location, err := query.location()
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return snapshotQuery{}, time.Time{}, nil, false
+	}
+
+	if query.Timestamp == "" {
+		return query, time.Time{}, location, true
+	}
+
+	timestamp, err := parseTimestamp(query.Timestamp, location)
+	if err != nil {
+		_ = c.Error(err)
+		return snapshotQuery{}, time.Time{}, nil, false
+	}
+	return query, timestamp, location, true
+}
+
+// setSnapshotHeaders gives the slot served and lets clients cache snapshots
+// forever when the requested slot has data, a snapped one may change as new
+// slots are ingested.
+func setSnapshotHeaders(c *gin.Context, requested, effective time.Time) {
+	c.Header("X-Snapshot-Timestamp", effective.UTC().Format(time.RFC3339))
+	if !requested.IsZero() && requested.Equal(effective) {
+		c.Header("Cache-Control", "max-age=86400, immutable")
+	}
+}
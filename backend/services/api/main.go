@@ -1,50 +1,159 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
+	// The runtime image has no zoneinfo, the tz parameters rely on this copy.
+	_ "time/tzdata"
+
+	"github.com/getkin/kin-openapi/openapi3"
 
 	"github.com/oupo1337/velibs/backend/common/application"
+	"github.com/oupo1337/velibs/backend/common/config"
 	"github.com/oupo1337/velibs/backend/common/ginx"
+	"github.com/oupo1337/velibs/backend/common/metrics"
+	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+	"github.com/oupo1337/velibs/backend/services/api/events"
 	"github.com/oupo1337/velibs/backend/services/api/handlers"
+	"github.com/oupo1337/velibs/backend/services/api/openapi"
 )
 
-const serviceName = "velib-api"
+const (
+	serviceName = "velib-api"
+
+	maxStatusesAge = 30 * time.Minute
+)
 
 type dependencies struct {
+	db                *postgres.Database
 	statuses          *handlers.Statuses
 	ways              *handlers.BikeLanes
 	freeFloatingBikes *handlers.FreeFloatingBikes
+	timeline          *handlers.Timeline
+	export            *handlers.Export
+	broker            *events.Broker
+	events            *handlers.Events
+	alerts            *handlers.Alerts
+	areas             *handlers.Areas
 }
 
-func initDependencies() (dependencies, error) {
-	db, err := postgres.New(postgres.Configuration{
-		Username: os.Getenv("DATABASE_USERNAME"),
-		Password: os.Getenv("DATABASE_PASSWORD"),
-		Address:  os.Getenv("DATABASE_ADDRESS"),
-		Name:     os.Getenv("DATABASE_NAME"),
-	})
+func databaseConfiguration(conf config.Config) postgres.Configuration {
+	applicationName := conf.Database.ApplicationName
+	if applicationName == "" {
+		applicationName = serviceName
+	}
+
+	return postgres.Configuration{
+		Username:         conf.Database.Username,
+		Password:         conf.Database.Password,
+		Address:          conf.Database.Address,
+		Name:             conf.Database.Name,
+		ReadAddress:      conf.Database.ReadAddress,
+		SSLMode:          conf.Database.SSLMode,
+		SSLRootCert:      conf.Database.SSLRootCert,
+		SSLCert:          conf.Database.SSLCert,
+		SSLKey:           conf.Database.SSLKey,
+		MinConns:         conf.Database.MinConns,
+		MaxConns:         conf.Database.MaxConns,
+		MaxConnIdleTime:  conf.Database.MaxConnIdleTime,
+		MaxConnLifetime:  conf.Database.MaxConnLifetime,
+		StatementTimeout: conf.Database.StatementTimeout,
+		ApplicationName:  applicationName,
+	}
+}
+
+func initDependencies(conf config.Config) (dependencies, error) {
+	db, err := postgres.New(databaseConfiguration(conf))
 	if err != nil {
 		return dependencies{}, fmt.Errorf("postgres.New error: %w", err)
 	}
 
+	if err := metrics.Register(db.Collector()); err != nil {
+		return dependencies{}, fmt.Errorf("metrics.Register error: %w", err)
+	}
+
+	keys, err := alertKeys(conf.API.Alerts.Keys)
+	if err != nil {
+		return dependencies{}, fmt.Errorf("alertKeys error: %w", err)
+	}
+
+	broker := events.NewBroker(db)
+
 	return dependencies{
+		db:                db,
 		statuses:          handlers.NewStatuses(db),
 		ways:              handlers.NewBikeLanes(db),
 		freeFloatingBikes: handlers.NewFreeFloatingBikes(db),
+		timeline:          handlers.NewTimeline(db),
+		export:            handlers.NewExport(db),
+		broker:            broker,
+		events:            handlers.NewEvents(broker),
+		alerts: handlers.NewAlerts(db, handlers.AlertsConfig{
+			Keys:         keys,
+			MaxActive:    conf.API.Alerts.MaxActive,
+			CreateLimit:  conf.API.Alerts.CreateLimit,
+			CreateWindow: conf.API.Alerts.CreateWindow,
+		}),
+		areas: handlers.NewAreas(db),
 	}, nil
 }
 
-func initRouter(deps dependencies) application.Service {
-	router := ginx.New(serviceName)
+// alertKeys reads the "owner:key" pairs of the alert callers into the owners
+// by key.
+func alertKeys(pairs []string) (map[string]string, error) {
+	keys := make(map[string]string, len(pairs))
+	for i, pair := range pairs {
+		owner, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || owner == "" || key == "" {
+			return nil, fmt.Errorf("invalid alerts key #%d: expected owner:key", i+1)
+		}
+		if _, ok := keys[key]; ok {
+			return nil, fmt.Errorf("alerts key of %q is given twice", owner)
+		}
+		keys[key] = owner
+	}
+	return keys, nil
+}
+
+func statusesFreshness(db *postgres.Database) ginx.Check {
+	return func(ctx context.Context) error {
+		last, err := db.LastIngestedSlot(ctx, domain.DatasetStatuses)
+		if err != nil {
+			return fmt.Errorf("db.LastIngestedSlot error: %w", err)
+		}
+
+		if age := time.Since(last); age > maxStatusesAge {
+			return fmt.Errorf("latest statuses are %s old", age.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+func initRouter(conf config.Config, deps dependencies, spec *openapi3.T) (*ginx.Engine, error) {
+	router := ginx.New(serviceName, conf.Application)
+	router.AddReadinessCheck("database", deps.db.Ping)
+	router.AddReadinessCheck("statuses", statusesFreshness(deps.db))
+
+	document, err := openapi.Handler(spec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi.Handler error: %w", err)
+	}
+	router.Use(openapi.NewValidation(spec))
+
+	router.GET("/api/openapi.json", document)
 
 	router.GET("/api/v2/timestamps", deps.statuses.GetMinMaxTimestamps)
+	router.GET("/api/v2/timeline/:dataset", deps.timeline.GetTimeline)
 
 	router.GET("/api/v1/stations.geojson", deps.statuses.GetStationsStatuses)
 	router.GET("/api/v1/districts.geojson", deps.statuses.GetAdministrativeDistrictsStatuses)
 	router.GET("/api/v1/boroughs.geojson", deps.statuses.GetBoroughs)
+	router.GET("/api/v1/communes.geojson", deps.statuses.GetCommunes)
 	router.GET("/api/v1/bikelanes.geojson", deps.ways.FetchBikeLanes)
 
 	router.GET("/api/v1/freefloatingbikes.geojson", deps.freeFloatingBikes.GetFreeFloatingBikes)
@@ -53,20 +162,71 @@ func initRouter(deps dependencies) application.Service {
 	router.GET("/api/v1/timeseries", deps.statuses.GetStationTimeSeries)
 	router.GET("/api/v1/distributions", deps.statuses.GetStationDistribution)
 
-	return router
+	router.GET("/api/v1/boroughs/:name/timeseries", deps.areas.GetBoroughTimeSeries)
+	router.GET("/api/v1/districts/:name/timeseries", deps.areas.GetDistrictTimeSeries)
+	router.GET("/api/v1/rankings/:area", deps.areas.GetRankings)
+
+	router.GET("/api/v1/export/statuses", deps.export.ExportStatuses)
+
+	router.GET("/api/v1/events", deps.events.StreamEvents)
+
+	alerts := router.Group("/api/v1/alerts", deps.alerts.Authenticate)
+	alerts.POST("", deps.alerts.CreateAlert)
+	alerts.GET("/:id", deps.alerts.GetAlert)
+	alerts.DELETE("/:id", deps.alerts.DeleteAlert)
+	alerts.GET("/:id/deliveries", deps.alerts.GetAlertDeliveries)
+
+	return router, nil
 }
 
 func main() {
-	app := application.New(serviceName)
+	conf, err := config.Load()
+	if err != nil {
+		slog.Error("config.Load error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := postgres.Migrate(context.Background(), databaseConfiguration(conf), os.Args[2:], os.Stdout); err != nil {
+			slog.Error("postgres.Migrate error", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	app, err := application.New(serviceName, conf)
+	if err != nil {
+		slog.Error("application.New error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	deps, err := initDependencies()
+	deps, err := initDependencies(conf)
 	if err != nil {
 		slog.Error("initDependencies error", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	app.AddCloser("database", func(context.Context) error {
+		deps.db.Close()
+		return nil
+	})
+
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		slog.Error("openapi.Load error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	router := initRouter(deps)
+	router, err := initRouter(conf, deps, spec)
+	if err != nil {
+		slog.Error("initRouter error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	app.AddServices(router)
-	app.Run()
+	// The broker is stopped first so that the event streams end and don't hold
+	// the router's shutdown.
+	app.AddServices(router, deps.broker)
+	if err := app.Run(); err != nil {
+		slog.Error("app.Run error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oupo1337/velibs/backend/common/config"
+	"github.com/oupo1337/velibs/backend/services/api/openapi"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatalf("openapi.Load error: %v", err)
+	}
+
+	conf := config.Config{}
+	router, err := initRouter(conf, dependencies{}, spec)
+	if err != nil {
+		t.Fatalf("initRouter error: %v", err)
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+
+		path := openapi.Path(route.Path)
+		registered[route.Method+" "+path] = true
+
+		item := spec.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("%s %s is registered without an entry in openapi.yaml", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				t.Errorf("%s %s is in openapi.yaml but no route serves it", method, path)
+			}
+		}
+	}
+}
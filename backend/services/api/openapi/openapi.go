@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+func init() {
+	// The validation errors end up in the problem details, the reason is
+	// enough without the whole schema.
+	openapi3.SchemaErrorDetailsDisabled = true
+}
+
+// Load parses the OpenAPI document of the api and checks it is valid.
+func Load(ctx context.Context) (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("loader.LoadFromData error: %w", err)
+	}
+
+	if err := spec.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("spec.Validate error: %w", err)
+	}
+	return spec, nil
+}
+
+// Path converts the path of a gin route to the one of its OpenAPI entry.
+func Path(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Handler serves the document as JSON.
+func Handler(spec *openapi3.T) (gin.HandlerFunc, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal error: %w", err)
+	}
+
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, gin.MIMEJSON, data)
+	}, nil
+}
+
+// NewValidation rejects the requests whose parameters or body don't match
+// the operation of their route. Routes missing from spec aren't checked.
+func NewValidation(spec *openapi3.T) gin.HandlerFunc {
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		path := Path(c.FullPath())
+		item := spec.Paths.Find(path)
+		if item == nil {
+			c.Next()
+			return
+		}
+		operation := item.GetOperation(c.Request.Method)
+		if operation == nil {
+			c.Next()
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Options:    options,
+			Route: &routers.Route{
+				Spec:      spec,
+				Path:      path,
+				PathItem:  item,
+				Method:    c.Request.Method,
+				Operation: operation,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			_ = c.Error(err).SetType(gin.ErrorTypeBind)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
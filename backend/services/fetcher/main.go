@@ -1,76 +1,204 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
 
 	"github.com/oupo1337/velibs/backend/common/application"
+	"github.com/oupo1337/velibs/backend/common/config"
 	"github.com/oupo1337/velibs/backend/common/cronx"
 	"github.com/oupo1337/velibs/backend/common/ginx"
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 	"github.com/oupo1337/velibs/backend/services/fetcher/tasks"
 )
 
-const serviceName = "velib-fetcher"
+const (
+	serviceName = "velib-fetcher"
+
+	frequentJobLease   = 15 * time.Minute
+	frequentJobTimeout = 8 * time.Minute
+	dailyJobLease      = 1 * time.Hour
+	dailyJobTimeout    = 30 * time.Minute
+	jobJitter          = 30 * time.Second
+	maxFrequentJobAge  = 25 * time.Minute
+)
 
 type dependencies struct {
+	db   *postgres.Database
 	cron *cronx.Cron
 }
 
-func initDependencies() (dependencies, error) {
-	db, err := postgres.New(postgres.Configuration{
-		Username: os.Getenv("DATABASE_USERNAME"),
-		Password: os.Getenv("DATABASE_PASSWORD"),
-		Address:  os.Getenv("DATABASE_ADDRESS"),
-		Name:     os.Getenv("DATABASE_NAME"),
-	})
+func databaseConfiguration(conf config.Config) postgres.Configuration {
+	applicationName := conf.Database.ApplicationName
+	if applicationName == "" {
+		applicationName = serviceName
+	}
+
+	return postgres.Configuration{
+		Username:         conf.Database.Username,
+		Password:         conf.Database.Password,
+		Address:          conf.Database.Address,
+		Name:             conf.Database.Name,
+		SSLMode:          conf.Database.SSLMode,
+		SSLRootCert:      conf.Database.SSLRootCert,
+		SSLCert:          conf.Database.SSLCert,
+		SSLKey:           conf.Database.SSLKey,
+		MinConns:         conf.Database.MinConns,
+		MaxConns:         conf.Database.MaxConns,
+		MaxConnIdleTime:  conf.Database.MaxConnIdleTime,
+		MaxConnLifetime:  conf.Database.MaxConnLifetime,
+		StatementTimeout: conf.Database.StatementTimeout,
+		ApplicationName:  applicationName,
+	}
+}
+
+func initDependencies(conf config.Config) (dependencies, error) {
+	db, err := postgres.New(databaseConfiguration(conf))
 	if err != nil {
 		return dependencies{}, fmt.Errorf("postgres.New error: %w", err)
 	}
 
-	districts := tasks.NewAdministrativeDistricts(db)
-	boroughs := tasks.NewBoroughs(db)
-	stations := tasks.NewStations(db)
-	statuses := tasks.NewStatuses(db)
-	bikeLanes := tasks.NewBikeLanes(db)
-	freeFloatingBikes := tasks.NewFreeFloatingBikes(db)
+	if err := metrics.Register(db.Collector()); err != nil {
+		return dependencies{}, fmt.Errorf("metrics.Register error: %w", err)
+	}
+
+	feeds := conf.Fetcher.Feeds
+	districts := tasks.NewAdministrativeDistricts(db, feeds.AdministrativeDistricts)
+	boroughs := tasks.NewBoroughs(db, feeds.Boroughs)
+	communes := tasks.NewCommunes(db, feeds.Communes)
+	stations := tasks.NewStations(db, feeds.Stations)
+	alerts := tasks.NewAlerts(db, conf.Fetcher.Alerts.Timeout, conf.Fetcher.Alerts.MaxAttempts, conf.Fetcher.Alerts.AllowPrivateWebhooks)
+	statuses := tasks.NewStatuses(db, feeds.Statuses, alerts)
+	bikeLanes := tasks.NewBikeLanes(db, feeds.BikeLanes)
+	freeFloatingBikes := tasks.NewFreeFloatingBikes(db, feeds.FreeFloatingBikes)
+
+	identity, err := os.Hostname()
+	if err != nil {
+		return dependencies{}, fmt.Errorf("os.Hostname error: %w", err)
+	}
+
+	frequentJob := []cronx.JobOption{
+		cronx.WithLease(frequentJobLease),
+		cronx.WithTimeout(frequentJobTimeout),
+		cronx.WithOverlapPolicy(cronx.SkipIfRunning),
+	}
+	dailyJob := []cronx.JobOption{
+		cronx.WithLease(dailyJobLease),
+		cronx.WithTimeout(dailyJobTimeout),
+		cronx.WithOverlapPolicy(cronx.SkipIfRunning),
+		cronx.WithJitter(jobJitter),
+	}
 
-	c := cronx.New()
-	if err := c.AddFunc("0 */10 * * * *", "update.FreeFloatingBikes", freeFloatingBikes.UpdateFreeFloatingBikes); err != nil {
+	schedules := conf.Fetcher.Schedules
+	c := cronx.New(cronx.WithLocker(db, identity))
+	if err := c.AddFunc(schedules.FreeFloatingBikes, "update.FreeFloatingBikes", freeFloatingBikes.UpdateFreeFloatingBikes, frequentJob...); err != nil {
 		return dependencies{}, fmt.Errorf("c.AddFunc error: %w", err)
 	}
-	if err := c.AddFunc("0 */10 * * * *", "update.Statuses", statuses.UpdateStatuses); err != nil {
+	if err := c.AddFunc(schedules.Statuses, "update.Statuses", statuses.UpdateStatuses, frequentJob...); err != nil {
 		return dependencies{}, fmt.Errorf("c.AddFunc error: %w", err)
 	}
-	if err := c.AddFunc("0 0 0 * * *", "update.Stations", stations.UpdateStations); err != nil {
+	if err := c.AddFunc(schedules.AlertDeliveries, "deliver.Alerts", alerts.DeliverAlerts, frequentJob...); err != nil {
 		return dependencies{}, fmt.Errorf("c.AddFunc error: %w", err)
 	}
-	if err := c.AddFunc("0 0 0 * * *", "update.BikeLanes", bikeLanes.UpdateBikeLanes); err != nil {
+	if err := c.AddFunc(schedules.Stations, "update.Stations", stations.UpdateStations, dailyJob...); err != nil {
+		return dependencies{}, fmt.Errorf("c.AddFunc error: %w", err)
+	}
+	if err := c.AddFunc(schedules.BikeLanes, "update.BikeLanes", bikeLanes.UpdateBikeLanes, dailyJob...); err != nil {
 		return dependencies{}, fmt.Errorf("c.AddFunc error: %w", err)
 	}
 
 	bikeLanes.Run()
 	districts.Run()
 	boroughs.Run()
+	communes.Run()
 	stations.Run()
 
+	// Catch up on the current slot right away instead of waiting for the next
+	// tick, slots missed while the fetcher was down are recorded as gaps.
+	statuses.Run()
+	freeFloatingBikes.Run()
+
 	return dependencies{
+		db:   db,
 		cron: c,
 	}, nil
 }
 
+type leadersResponse struct {
+	Replica string            `json:"replica"`
+	Leaders map[string]string `json:"leaders"`
+}
+
+func getLeaders(c *cronx.Cron) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		leaders, err := c.Leaders(ctx.Request.Context())
+		if err != nil {
+			_ = ctx.Error(fmt.Errorf("cron.Leaders error: %w", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, leadersResponse{
+			Replica: c.Identity(),
+			Leaders: leaders,
+		})
+	}
+}
+
+func jobFreshness(c *cronx.Cron, name string) ginx.Check {
+	return func(_ context.Context) error {
+		if age := time.Since(c.LastSuccess(name)); age > maxFrequentJobAge {
+			return fmt.Errorf("%s last succeeded %s ago", name, age.Round(time.Second))
+		}
+		return nil
+	}
+}
+
 func main() {
-	app := application.New(serviceName)
+	conf, err := config.Load()
+	if err != nil {
+		slog.Error("config.Load error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := postgres.Migrate(context.Background(), databaseConfiguration(conf), os.Args[2:], os.Stdout); err != nil {
+			slog.Error("postgres.Migrate error", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	app, err := application.New(serviceName, conf)
+	if err != nil {
+		slog.Error("application.New error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	deps, err := initDependencies()
+	deps, err := initDependencies(conf)
 	if err != nil {
 		slog.Error("initDependencies error", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	app.AddCloser("database", func(context.Context) error {
+		deps.db.Close()
+		return nil
+	})
 
-	router := ginx.New(serviceName)
+	router := ginx.New(serviceName, conf.Application)
+	router.GET("/leaders", getLeaders(deps.cron))
+	router.AddReadinessCheck("database", deps.db.Ping)
+	router.AddReadinessCheck("update.Statuses", jobFreshness(deps.cron, "update.Statuses"))
+	router.AddReadinessCheck("update.FreeFloatingBikes", jobFreshness(deps.cron, "update.FreeFloatingBikes"))
 
 	app.AddServices(router, deps.cron)
-	app.Run()
+	if err := app.Run(); err != nil {
+		slog.Error("app.Run error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 }
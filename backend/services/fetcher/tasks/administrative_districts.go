@@ -85,6 +85,10 @@ func (a *AdministrativeDistricts) updateAdministrativeDistricts(ctx context.Cont
 	if err := a.db.InsertAdministrativeDistricts(ctx, districts); err != nil {
 		return fmt.Errorf("db.InsertAdministrativeDistricts failed: %w", err)
 	}
+
+	if err := a.db.RefreshStationAreas(ctx); err != nil {
+		return fmt.Errorf("db.RefreshStationAreas failed: %w", err)
+	}
 	return nil
 }
 
@@ -99,9 +103,9 @@ func (a *AdministrativeDistricts) Run() {
 	}
 }
 
-func NewAdministrativeDistricts(db *postgres.Database) *AdministrativeDistricts {
+func NewAdministrativeDistricts(db *postgres.Database, url string) *AdministrativeDistricts {
 	return &AdministrativeDistricts{
-		url: "https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/quartier_paris/exports/geojson?lang=fr&timezone=Europe%2FBerlin",
+		url: url,
 		db:  db,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
@@ -0,0 +1,306 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+const (
+	SignatureHeader = "X-Velib-Signature"
+	DeliveryHeader  = "X-Velib-Delivery"
+
+	deliveryBatchSize = 100
+	firstRetryDelay   = 30 * time.Second
+	maxRetryDelay     = 1 * time.Hour
+
+	// deliveryWorkers bounds the webhooks posted at once, so that an
+	// unresponsive endpoint only holds one of them.
+	deliveryWorkers = 8
+)
+
+var errPrivateAddress = errors.New("webhook resolves to a private address")
+
+type Alerts struct {
+	db          *postgres.Database
+	client      *http.Client
+	maxAttempts int
+}
+
+type alertKey struct {
+	alertID   uuid.UUID
+	stationID int64
+}
+
+// EvaluateAlerts checks the alerts against the statuses of a slot, saves
+// where every station stands and queues the webhooks to deliver.
+func (a *Alerts) EvaluateAlerts(ctx context.Context, timestamp time.Time, statuses []domain.StationStatus) error {
+	alerts, err := a.db.GetAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetAlerts error: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	states, err := a.db.GetAlertStates(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetAlertStates error: %w", err)
+	}
+	previous := make(map[alertKey]domain.AlertState, len(states))
+	for _, state := range states {
+		previous[alertKey{state.AlertID, state.StationID}] = state
+	}
+
+	byStation := make(map[int64]domain.StationStatus, len(statuses))
+	for _, status := range statuses {
+		byStation[int64(status.StationID)] = status
+	}
+
+	var updated []domain.AlertState
+	var deliveries []domain.AlertDelivery
+	for _, alert := range alerts {
+		for _, stationID := range alert.StationIDs {
+			status, ok := byStation[stationID]
+			if !ok {
+				continue
+			}
+
+			state, ok := previous[alertKey{alert.ID, stationID}]
+			if !ok {
+				state = domain.AlertState{AlertID: alert.ID, StationID: stationID}
+			}
+
+			value := alert.Value(status)
+			next, triggered := evaluate(alert, state, value, timestamp)
+			if next != state {
+				updated = append(updated, next)
+			}
+			if !triggered {
+				continue
+			}
+
+			payload, err := json.Marshal(domain.AlertPayload{
+				AlertID:   alert.ID,
+				StationID: stationID,
+				Metric:    alert.Metric,
+				Operator:  alert.Operator,
+				Threshold: alert.Threshold,
+				Value:     value,
+				Since:     *next.MatchingSince,
+				Timestamp: timestamp,
+			})
+			if err != nil {
+				return fmt.Errorf("json.Marshal error: %w", err)
+			}
+			deliveries = append(deliveries, domain.AlertDelivery{
+				AlertID:   alert.ID,
+				StationID: stationID,
+				Timestamp: timestamp,
+				Payload:   payload,
+			})
+		}
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+	if err := a.db.UpdateAlerts(ctx, updated, deliveries); err != nil {
+		return fmt.Errorf("db.UpdateAlerts error: %w", err)
+	}
+	slog.InfoContext(ctx, "alerts evaluated", slog.Int("states", len(updated)), slog.Int("triggered", len(deliveries)))
+	return nil
+}
+
+// evaluate returns the next state of a station for alert and whether the
+// alert triggers: once the condition has held for the alert's duration, once
+// per matching period and at most once per cooldown.
+func evaluate(alert domain.Alert, state domain.AlertState, value int, timestamp time.Time) (domain.AlertState, bool) {
+	if !alert.Matches(value) {
+		state.MatchingSince = nil
+		return state, false
+	}
+
+	if state.MatchingSince == nil {
+		state.MatchingSince = &timestamp
+	}
+	if timestamp.Sub(*state.MatchingSince) < alert.Duration {
+		return state, false
+	}
+	if state.LastTriggered != nil {
+		if !state.LastTriggered.Before(*state.MatchingSince) {
+			return state, false
+		}
+		if timestamp.Sub(*state.LastTriggered) < alert.Cooldown {
+			return state, false
+		}
+	}
+
+	state.LastTriggered = &timestamp
+	return state, true
+}
+
+// sign authenticates a payload the way receivers check it: the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the alert's secret.
+func sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func (a *Alerts) post(ctx context.Context, delivery domain.AlertDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.WebhookURL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("http.NewRequestWithContext error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, sign(delivery.Secret, time.Now().Unix(), delivery.Payload))
+
+	response, err := a.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("client.Do error: %w", err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// retryDelay doubles with every attempt.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+func (a *Alerts) deliver(ctx context.Context, delivery domain.AlertDelivery) error {
+	statusCode, err := a.post(ctx, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.Status = domain.DeliveryDelivered
+	delivery.NextAttemptAt = now
+	delivery.Error = nil
+	delivery.DeliveredAt = nil
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+
+	switch {
+	case err == nil:
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= a.maxAttempts:
+		message := err.Error()
+		delivery.Status = domain.DeliveryFailed
+		delivery.Error = &message
+	default:
+		message := err.Error()
+		delivery.Status = domain.DeliveryPending
+		delivery.Error = &message
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+	}
+
+	if err := a.db.RecordAlertDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("db.RecordAlertDelivery error: %w", err)
+	}
+	return nil
+}
+
+// DeliverAlerts attempts the deliveries that are due, a few at a time. The
+// failed ones are retried with an exponential backoff until they run out of
+// attempts.
+func (a *Alerts) DeliverAlerts(ctx context.Context) error {
+	for {
+		deliveries, err := a.db.ClaimAlertDeliveries(ctx, deliveryBatchSize)
+		if err != nil {
+			return fmt.Errorf("db.ClaimAlertDeliveries error: %w", err)
+		}
+
+		var group errgroup.Group
+		group.SetLimit(deliveryWorkers)
+		for _, delivery := range deliveries {
+			group.Go(func() error {
+				if err := a.deliver(ctx, delivery); err != nil {
+					return fmt.Errorf("a.deliver error: %w", err)
+				}
+				return nil
+			})
+		}
+		if err := group.Wait(); err != nil {
+			return err
+		}
+
+		if len(deliveries) < deliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+// refusePrivate keeps webhooks from reaching the services next to the
+// fetcher, the address is checked once resolved so DNS can't get around it.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("net.SplitHostPort error: %w", err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+func NewAlerts(db *postgres.Database, timeout time.Duration, maxAttempts int, allowPrivate bool) *Alerts {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Alerts{
+		db:          db,
+		maxAttempts: maxAttempts,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
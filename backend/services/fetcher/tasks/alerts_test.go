@@ -0,0 +1,173 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+func at(minutes int) *time.Time {
+	t := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC).Add(time.Duration(minutes) * time.Minute)
+	return &t
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestEvaluate(t *testing.T) {
+	alert := domain.Alert{
+		Operator:  domain.AlertAtMost,
+		Threshold: 2,
+		Duration:  20 * time.Minute,
+		Cooldown:  time.Hour,
+	}
+
+	tests := []struct {
+		name      string
+		alert     domain.Alert
+		state     domain.AlertState
+		value     int
+		timestamp *time.Time
+		want      domain.AlertState
+		triggered bool
+	}{
+		{
+			name:      "condition no longer holds",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(0)},
+			value:     5,
+			timestamp: at(10),
+			want:      domain.AlertState{},
+		},
+		{
+			name:      "condition starts holding",
+			alert:     alert,
+			value:     1,
+			timestamp: at(0),
+			want:      domain.AlertState{MatchingSince: at(0)},
+		},
+		{
+			name:      "held for less than the duration",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(0)},
+			value:     2,
+			timestamp: at(10),
+			want:      domain.AlertState{MatchingSince: at(0)},
+		},
+		{
+			name:      "held for the duration",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(0)},
+			value:     2,
+			timestamp: at(20),
+			want:      domain.AlertState{MatchingSince: at(0), LastTriggered: at(20)},
+			triggered: true,
+		},
+		{
+			name:      "once per matching period",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(0), LastTriggered: at(20)},
+			value:     0,
+			timestamp: at(180),
+			want:      domain.AlertState{MatchingSince: at(0), LastTriggered: at(20)},
+		},
+		{
+			name:      "new period within the cooldown",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(30), LastTriggered: at(20)},
+			value:     0,
+			timestamp: at(50),
+			want:      domain.AlertState{MatchingSince: at(30), LastTriggered: at(20)},
+		},
+		{
+			name:      "new period after the cooldown",
+			alert:     alert,
+			state:     domain.AlertState{MatchingSince: at(120), LastTriggered: at(20)},
+			value:     0,
+			timestamp: at(140),
+			want:      domain.AlertState{MatchingSince: at(120), LastTriggered: at(140)},
+			triggered: true,
+		},
+		{
+			name:      "no duration triggers right away",
+			alert:     domain.Alert{Operator: domain.AlertAtLeast, Threshold: 10},
+			value:     10,
+			timestamp: at(0),
+			want:      domain.AlertState{MatchingSince: at(0), LastTriggered: at(0)},
+			triggered: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, triggered := evaluate(test.alert, test.state, test.value, *test.timestamp)
+			if triggered != test.triggered {
+				t.Errorf("triggered = %v, want %v", triggered, test.triggered)
+			}
+			if !sameTime(got.MatchingSince, test.want.MatchingSince) {
+				t.Errorf("MatchingSince = %v, want %v", got.MatchingSince, test.want.MatchingSince)
+			}
+			if !sameTime(got.LastTriggered, test.want.LastTriggered) {
+				t.Errorf("LastTriggered = %v, want %v", got.LastTriggered, test.want.LastTriggered)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 20, want: time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := retryDelay(test.attempts); got != test.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			name:      "hmac of the timestamp and the body",
+			secret:    "secret",
+			timestamp: 1700000000,
+			body:      `{"alert":1}`,
+			want:      "t=1700000000,v1=fc2e4afacb78d7837b8eb7f2ef00a76c4f8051374b584c89d36bd749756cfccf",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sign(test.secret, test.timestamp, []byte(test.body)); got != test.want {
+				t.Errorf("sign = %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	if sign("secret", 1700000000, []byte(`{"alert":1}`)) == sign("other", 1700000000, []byte(`{"alert":1}`)) {
+		t.Error("sign doesn't depend on the secret")
+	}
+	if sign("secret", 1700000000, []byte(`{"alert":1}`)) == sign("secret", 1700000001, []byte(`{"alert":1}`)) {
+		t.Error("sign doesn't depend on the timestamp")
+	}
+}
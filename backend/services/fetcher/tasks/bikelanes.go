@@ -88,9 +88,9 @@ func (b *BikeLanes) Run() {
 	}
 }
 
-func NewBikeLanes(db *postgres.Database) *BikeLanes {
+func NewBikeLanes(db *postgres.Database, url string) *BikeLanes {
 	return &BikeLanes{
-		url: "https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/amenagements-cyclables/exports/geojson?lang=fr&timezone=Europe%2FBerlin",
+		url: url,
 		db:  db,
 		client: &http.Client{
 			Timeout: 1 * time.Minute,
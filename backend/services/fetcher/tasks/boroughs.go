@@ -84,6 +84,10 @@ func (a *Boroughs) updateBoroughs(ctx context.Context) error {
 	if err := a.db.InsertBoroughs(ctx, boroughs); err != nil {
 		return fmt.Errorf("db.InsertBoroughs error: %w", err)
 	}
+
+	if err := a.db.RefreshStationAreas(ctx); err != nil {
+		return fmt.Errorf("db.RefreshStationAreas error: %w", err)
+	}
 	return nil
 }
 
@@ -98,9 +102,9 @@ func (b *Boroughs) Run() {
 	}
 }
 
-func NewBoroughs(db *postgres.Database) *Boroughs {
+func NewBoroughs(db *postgres.Database, url string) *Boroughs {
 	return &Boroughs{
-		url: "https://opendata.paris.fr/api/explore/v2.1/catalog/datasets/arrondissements/exports/geojson?lang=fr&timezone=Europe%2FBerlin",
+		url: url,
 		db:  db,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/oupo1337/velibs/backend/common/tracing"
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+// Communes loads the boundaries of the communes of Île-de-France, so that the
+// stations of Vélib' Métropole outside of Paris can be told apart. The source
+// is either an http(s) URL or a local GeoJSON file.
+type Communes struct {
+	source string
+	db     *postgres.Database
+	client *http.Client
+}
+
+func (c *Communes) readCommunes(path string) (domain.CommunesGeoJSON, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return domain.CommunesGeoJSON{}, fmt.Errorf("os.ReadFile error: %w", err)
+	}
+
+	var data domain.CommunesGeoJSON
+	if err := json.Unmarshal(body, &data); err != nil {
+		return domain.CommunesGeoJSON{}, fmt.Errorf("json.Unmarshal error: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Communes) fetchCommunes(ctx context.Context) (domain.CommunesGeoJSON, error) {
+	if !strings.HasPrefix(c.source, "http://") && !strings.HasPrefix(c.source, "https://") {
+		return c.readCommunes(strings.TrimPrefix(c.source, "file://"))
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, otelhttptrace.NewClientTrace(ctx))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.source, nil)
+	if err != nil {
+		return domain.CommunesGeoJSON{}, fmt.Errorf("NewRequestWithContext error: %w", err)
+	}
+
+	retryer := retry.NewFibonacci(1 * time.Second)
+	retryer = retry.WithJitter(100*time.Millisecond, retryer)
+	retryer = retry.WithMaxRetries(7, retryer)
+
+	var data domain.CommunesGeoJSON
+	if err := retry.Do(ctx, retryer, func(ctx context.Context) error {
+		response, err := c.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("c.client.Do error: %w", retry.RetryableError(err))
+		}
+		defer func() {
+			if err := response.Body.Close(); err != nil {
+				slog.Error("response.Body.Close error", slog.String("error", err.Error()))
+			}
+		}()
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("io.ReadAll error: %w", retry.RetryableError(err))
+		}
+
+		if err := json.Unmarshal(body, &data); err != nil {
+			return fmt.Errorf("json.Unmarshal error: %w", retry.RetryableError(err))
+		}
+		return nil
+	}); err != nil {
+		return domain.CommunesGeoJSON{}, fmt.Errorf("retry.Do error: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Communes) updateCommunes(ctx context.Context) error {
+	slog.InfoContext(ctx, "updating Île-de-France communes")
+
+	hasCommunes, err := c.db.HasCommunes(ctx)
+	if err != nil {
+		return fmt.Errorf("db.HasCommunes error: %w", err)
+	}
+
+	if hasCommunes {
+		slog.InfoContext(ctx, "database already contains Île-de-France communes")
+		return nil
+	}
+
+	communes, err := c.fetchCommunes(ctx)
+	if err != nil {
+		return fmt.Errorf("fetchCommunes error: %w", err)
+	}
+
+	if err := c.db.InsertCommunes(ctx, communes); err != nil {
+		return fmt.Errorf("db.InsertCommunes error: %w", err)
+	}
+
+	if err := c.db.RefreshStationAreas(ctx); err != nil {
+		return fmt.Errorf("db.RefreshStationAreas error: %w", err)
+	}
+	return nil
+}
+
+func (c *Communes) Run() {
+	ctx, span := tracing.Start(context.Background(), "update.Communes")
+	defer span.End()
+
+	if err := c.updateCommunes(ctx); err != nil {
+		span.SetStatus(codes.Error, "updateCommunes failed")
+		span.RecordError(err)
+		slog.ErrorContext(ctx, "updateCommunes failed", slog.String("error", err.Error()))
+	}
+}
+
+func NewCommunes(db *postgres.Database, source string) *Communes {
+	return &Communes{
+		source: source,
+		db:     db,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
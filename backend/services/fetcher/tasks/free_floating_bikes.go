@@ -3,6 +3,7 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,7 +14,9 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/common/tracing"
+	"github.com/oupo1337/velibs/backend/common/validation"
 	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
@@ -33,11 +36,11 @@ type FreeFloatingBikesResponse struct {
 	} `json:"data"`
 }
 
-func (f *FreeFloatingBikes) fetchFreeFloatingBikes(ctx context.Context) ([]domain.FreeFloatingBike, error) {
+func (f *FreeFloatingBikes) fetchFreeFloatingBikes(ctx context.Context) (FreeFloatingBikesResponse, error) {
 	ctx = httptrace.WithClientTrace(ctx, otelhttptrace.NewClientTrace(ctx))
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("http.NewRequestWithContext error: %w", err)
+		return FreeFloatingBikesResponse{}, fmt.Errorf("http.NewRequestWithContext error: %w", err)
 	}
 
 	retryer := retry.NewFibonacci(1 * time.Second)
@@ -61,21 +64,44 @@ func (f *FreeFloatingBikes) fetchFreeFloatingBikes(ctx context.Context) ([]domai
 		}
 		return nil
 	}); err != nil {
-		return nil, fmt.Errorf("retry.Do error: %w", err)
+		return FreeFloatingBikesResponse{}, fmt.Errorf("retry.Do error: %w", err)
 	}
-	return data.Data.Bikes, nil
+	return data, nil
 }
 
 func (f *FreeFloatingBikes) UpdateFreeFloatingBikes(ctx context.Context) error {
 	slog.InfoContext(ctx, "fetching free floating bikes location")
 
-	freeFloatingBikes, err := f.fetchFreeFloatingBikes(ctx)
+	response, err := f.fetchFreeFloatingBikes(ctx)
 	if err != nil {
-		return fmt.Errorf("s.fetchStationsStatuses error: %w", err)
+		return fmt.Errorf("f.fetchFreeFloatingBikes error: %w", err)
 	}
+	metrics.SetFeedUpdated(domain.DatasetFreeFloatingBikes, time.Unix(int64(response.LastUpdated), 0))
+	freeFloatingBikes := response.Data.Bikes
 
-	if err := f.db.InsertFreeFloatingBikes(ctx, freeFloatingBikes); err != nil {
-		return fmt.Errorf("db.InsertStatuses error: %w", err)
+	report := validation.New(freeFloatingBikesRules()...).Validate(freeFloatingBikes)
+
+	timestamp := domain.Slot(time.Now())
+	if len(report.Valid) == 0 {
+		if err := reportQuality(ctx, f.db, domain.DatasetFreeFloatingBikes, timestamp, report); err != nil {
+			return fmt.Errorf("reportQuality error: %w", err)
+		}
+		return errNoValidRecord
+	}
+
+	err = f.db.InsertFreeFloatingBikes(ctx, timestamp, report.Valid)
+	if errors.Is(err, postgres.ErrSlotAlreadyIngested) {
+		slog.InfoContext(ctx, "slot already ingested", slog.Time("timestamp", timestamp))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("db.InsertFreeFloatingBikes error: %w", err)
+	}
+
+	metrics.FreeFloatingBikesSeen.Set(float64(len(report.Valid)))
+
+	if err := reportQuality(ctx, f.db, domain.DatasetFreeFloatingBikes, timestamp, report); err != nil {
+		return fmt.Errorf("reportQuality error: %w", err)
 	}
 	return nil
 }
@@ -91,9 +117,9 @@ func (f *FreeFloatingBikes) Run() {
 	}
 }
 
-func NewFreeFloatingBikes(db *postgres.Database) *FreeFloatingBikes {
+func NewFreeFloatingBikes(db *postgres.Database, url string) *FreeFloatingBikes {
 	return &FreeFloatingBikes{
-		url: "https://data.lime.bike/api/partners/v2/gbfs/paris/free_bike_status",
+		url: url,
 		db:  db,
 		client: &http.Client{
 			Timeout: 20 * time.Second,
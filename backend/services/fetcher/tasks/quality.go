@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oupo1337/velibs/backend/common/validation"
+	"github.com/oupo1337/velibs/backend/domain"
+	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
+)
+
+// errNoValidRecord skips the slots whose records were all rejected, which
+// would otherwise become the latest slot without any data.
+var errNoValidRecord = errors.New("no valid record, slot skipped")
+
+func reportQuality[T any](ctx context.Context, db *postgres.Database, dataset string, timestamp time.Time, report validation.Report[T]) error {
+	quality := domain.FetchQuality{
+		Dataset:   dataset,
+		Timestamp: timestamp,
+		Received:  report.Received(),
+		Accepted:  len(report.Valid),
+		Rejected:  len(report.Rejected),
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("quality.received", quality.Received),
+		attribute.Int("quality.accepted", quality.Accepted),
+		attribute.Int("quality.rejected", quality.Rejected),
+	)
+
+	attributes := []any{
+		slog.String("dataset", dataset),
+		slog.Int("received", quality.Received),
+		slog.Int("accepted", quality.Accepted),
+		slog.Int("rejected", quality.Rejected),
+	}
+	for rule, count := range report.RejectedByRule() {
+		attributes = append(attributes, slog.Int("rule."+rule, count))
+	}
+	slog.InfoContext(ctx, "feed validated", attributes...)
+
+	records := make([]domain.QuarantinedRecord, 0, len(report.Rejected))
+	for _, rejection := range report.Rejected {
+		records = append(records, domain.QuarantinedRecord{
+			Rule:    rejection.Rule,
+			Reason:  rejection.Reason,
+			Payload: rejection.Record,
+		})
+	}
+
+	if err := db.InsertFetchQuality(ctx, quality, records); err != nil {
+		return fmt.Errorf("db.InsertFetchQuality error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/oupo1337/velibs/backend/common/validation"
+	"github.com/oupo1337/velibs/backend/domain"
+)
+
+// Rough bounding box of Île-de-France, anything outside is a feed glitch.
+const (
+	minLatitude  = 48.1
+	maxLatitude  = 49.3
+	minLongitude = 1.4
+	maxLongitude = 3.6
+)
+
+func checkPosition(latitude, longitude float64) error {
+	if latitude < minLatitude || latitude > maxLatitude || longitude < minLongitude || longitude > maxLongitude {
+		return fmt.Errorf("position (%f, %f) is outside Île-de-France", latitude, longitude)
+	}
+	return nil
+}
+
+func statusesRules(capacities map[int64]int) []validation.Rule[domain.StationStatus] {
+	return []validation.Rule[domain.StationStatus]{
+		{
+			Name: "known_station",
+			Check: func(status domain.StationStatus) error {
+				if _, ok := capacities[int64(status.StationID)]; !ok {
+					return fmt.Errorf("unknown station %d", status.StationID)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "non_negative_counts",
+			Check: func(status domain.StationStatus) error {
+				if status.NumBikesAvailable < 0 || status.NumDocksAvailable < 0 || status.Mechanical() < 0 || status.Electric() < 0 {
+					return fmt.Errorf("negative count: bikes=%d docks=%d mechanical=%d electric=%d",
+						status.NumBikesAvailable, status.NumDocksAvailable, status.Mechanical(), status.Electric())
+				}
+				return nil
+			},
+		},
+		{
+			Name: "within_capacity",
+			Check: func(status domain.StationStatus) error {
+				capacity := capacities[int64(status.StationID)]
+				if bikes := status.Mechanical() + status.Electric(); bikes > capacity {
+					return fmt.Errorf("%d bikes above capacity %d", bikes, capacity)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func stationsRules() []validation.Rule[domain.StationInformation] {
+	return []validation.Rule[domain.StationInformation]{
+		{
+			Name: "non_negative_capacity",
+			Check: func(station domain.StationInformation) error {
+				if station.Capacity < 0 {
+					return fmt.Errorf("negative capacity %f", station.Capacity)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "valid_position",
+			Check: func(station domain.StationInformation) error {
+				return checkPosition(station.Latitude, station.Longitude)
+			},
+		},
+	}
+}
+
+func freeFloatingBikesRules() []validation.Rule[domain.FreeFloatingBike] {
+	return []validation.Rule[domain.FreeFloatingBike]{
+		{
+			Name: "valid_id",
+			Check: func(bike domain.FreeFloatingBike) error {
+				if bike.ID == uuid.Nil {
+					return fmt.Errorf("missing bike id")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "valid_position",
+			Check: func(bike domain.FreeFloatingBike) error {
+				return checkPosition(bike.Latitude, bike.Longitude)
+			},
+		},
+		{
+			Name: "non_negative_range",
+			Check: func(bike domain.FreeFloatingBike) error {
+				if bike.CurrentRangeMeters < 0 {
+					return fmt.Errorf("negative range %d", bike.CurrentRangeMeters)
+				}
+				return nil
+			},
+		},
+	}
+}
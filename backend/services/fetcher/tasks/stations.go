@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"github.com/oupo1337/velibs/backend/common/tracing"
+	"github.com/oupo1337/velibs/backend/common/validation"
 	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
@@ -73,9 +74,19 @@ func (s *Stations) UpdateStations(ctx context.Context) error {
 		return fmt.Errorf("fetchStationsInformation error: %w", err)
 	}
 
-	if err := s.db.InsertStations(ctx, stations); err != nil {
+	report := validation.New(stationsRules()...).Validate(stations)
+
+	if err := s.db.InsertStations(ctx, report.Valid); err != nil {
 		return fmt.Errorf("db.InsertStations error: %w", err)
 	}
+
+	if err := s.db.RefreshStationAreas(ctx); err != nil {
+		return fmt.Errorf("db.RefreshStationAreas error: %w", err)
+	}
+
+	if err := reportQuality(ctx, s.db, domain.DatasetStations, time.Now(), report); err != nil {
+		return fmt.Errorf("reportQuality error: %w", err)
+	}
 	return nil
 }
 
@@ -90,9 +101,9 @@ func (s *Stations) Run() {
 	}
 }
 
-func NewStations(db *postgres.Database) *Stations {
+func NewStations(db *postgres.Database, url string) *Stations {
 	return &Stations{
-		url: "https://velib-metropole-opendata.smovengo.cloud/opendata/Velib_Metropole/station_information.json",
+		url: url,
 		db:  db,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
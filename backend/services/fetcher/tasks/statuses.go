@@ -3,6 +3,7 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,7 +14,9 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/oupo1337/velibs/backend/common/metrics"
 	"github.com/oupo1337/velibs/backend/common/tracing"
+	"github.com/oupo1337/velibs/backend/common/validation"
 	"github.com/oupo1337/velibs/backend/domain"
 	"github.com/oupo1337/velibs/backend/infrastructure/postgres"
 )
@@ -22,6 +25,7 @@ type Statuses struct {
 	url    string
 	db     *postgres.Database
 	client *http.Client
+	alerts *Alerts
 }
 
 type StationStatusResponse struct {
@@ -32,11 +36,11 @@ type StationStatusResponse struct {
 	TTL              int64 `json:"ttl"`
 }
 
-func (s *Statuses) fetchStationsStatuses(ctx context.Context) ([]domain.StationStatus, error) {
+func (s *Statuses) fetchStationsStatuses(ctx context.Context) (StationStatusResponse, error) {
 	ctx = httptrace.WithClientTrace(ctx, otelhttptrace.NewClientTrace(ctx))
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("http.NewRequestWithContext error: %w", err)
+		return StationStatusResponse{}, fmt.Errorf("http.NewRequestWithContext error: %w", err)
 	}
 
 	retryer := retry.NewFibonacci(1 * time.Second)
@@ -60,22 +64,61 @@ func (s *Statuses) fetchStationsStatuses(ctx context.Context) ([]domain.StationS
 		}
 		return nil
 	}); err != nil {
-		return nil, fmt.Errorf("retry.Do error: %w", err)
+		return StationStatusResponse{}, fmt.Errorf("retry.Do error: %w", err)
 	}
-	return data.Data.StationsStatuses, nil
+	return data, nil
 }
 
 func (s *Statuses) UpdateStatuses(ctx context.Context) error {
 	slog.InfoContext(ctx, "fetching velib stations statuses")
 
-	statuses, err := s.fetchStationsStatuses(ctx)
+	response, err := s.fetchStationsStatuses(ctx)
 	if err != nil {
 		return fmt.Errorf("s.fetchStationsStatuses error: %w", err)
 	}
+	metrics.SetFeedUpdated(domain.DatasetStatuses, time.Unix(response.LastUpdatedOther, 0))
+	statuses := response.Data.StationsStatuses
 
-	if err := s.db.InsertStatuses(ctx, statuses); err != nil {
+	capacities, err := s.db.GetStationCapacities(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetStationCapacities error: %w", err)
+	}
+	report := validation.New(statusesRules(capacities)...).Validate(statuses)
+
+	timestamp := domain.Slot(time.Now())
+	if len(report.Valid) == 0 {
+		if err := reportQuality(ctx, s.db, domain.DatasetStatuses, timestamp, report); err != nil {
+			return fmt.Errorf("reportQuality error: %w", err)
+		}
+		return errNoValidRecord
+	}
+
+	err = s.db.InsertStatuses(ctx, timestamp, report.Valid)
+	if errors.Is(err, postgres.ErrSlotAlreadyIngested) {
+		slog.InfoContext(ctx, "slot already ingested", slog.Time("timestamp", timestamp))
+		return nil
+	}
+	if err != nil {
 		return fmt.Errorf("db.InsertStatuses error: %w", err)
 	}
+
+	mechanical, electric := 0, 0
+	for _, status := range report.Valid {
+		mechanical += status.Mechanical()
+		electric += status.Electric()
+	}
+	metrics.StationsReporting.Set(float64(len(report.Valid)))
+	metrics.BikesAvailable.WithLabelValues("mechanical").Set(float64(mechanical))
+	metrics.BikesAvailable.WithLabelValues("electric").Set(float64(electric))
+
+	if err := reportQuality(ctx, s.db, domain.DatasetStatuses, timestamp, report); err != nil {
+		return fmt.Errorf("reportQuality error: %w", err)
+	}
+
+	// The slot is saved already, alerts failing to evaluate must not fail it.
+	if err := s.alerts.EvaluateAlerts(ctx, timestamp, report.Valid); err != nil {
+		slog.ErrorContext(ctx, "alerts.EvaluateAlerts error", slog.String("error", err.Error()))
+	}
 	return nil
 }
 
@@ -90,10 +133,11 @@ func (s *Statuses) Run() {
 	}
 }
 
-func NewStatuses(db *postgres.Database) *Statuses {
+func NewStatuses(db *postgres.Database, url string, alerts *Alerts) *Statuses {
 	return &Statuses{
-		url: "https://velib-metropole-opendata.smovengo.cloud/opendata/Velib_Metropole/station_status.json",
-		db:  db,
+		url:    url,
+		db:     db,
+		alerts: alerts,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
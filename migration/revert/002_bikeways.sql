@@ -1,7 +0,0 @@
--- Revert velib:002_bicycle_path from pg
-
-BEGIN;
-
-DROP TABLE bikeways;
-
-COMMIT;
@@ -1,7 +0,0 @@
--- Verify velib:001_initial_schema on pg
-
-BEGIN;
-
--- XXX Add verifications here.
-
-ROLLBACK;
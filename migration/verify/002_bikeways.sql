@@ -1,7 +0,0 @@
--- Verify velib:002_bicycle_path on pg
-
-BEGIN;
-
--- XXX Add verifications here.
-
-ROLLBACK;
@@ -1,7 +0,0 @@
--- Verify velib:003_administrative_districts on pg
-
-BEGIN;
-
--- XXX Add verifications here.
-
-ROLLBACK;
@@ -1,7 +0,0 @@
--- Verify velib:004_boroughs on pg
-
-BEGIN;
-
--- XXX Add verifications here.
-
-ROLLBACK;
@@ -1,7 +0,0 @@
--- Verify velib:005_add_geo_indexes on pg
-
-BEGIN;
-
--- XXX Add verifications here.
-
-ROLLBACK;
@@ -62,8 +62,8 @@ interface GraphProps {
 function cleanTimeSeries(data: Timeseries[]) {
     return data.map(d => ({
         date: new Date(d.date),
-        mechanical: +d.mechanical,
-        electric: +d.electric,
+        mechanical: d.mechanical === null ? null : +d.mechanical,
+        electric: d.electric === null ? null : +d.electric,
     }));
 }
 
@@ -2,8 +2,8 @@ import type { Feature, FeatureCollection, Geometry, Point, Polygon} from 'geojso
 
 export interface Timeseries {
   date: Date
-  mechanical: number
-  electric: number
+  mechanical: number | null
+  electric: number | null
 }
 
 export interface StationInformation {
@@ -14,8 +14,8 @@ export interface StationInformation {
 
 export interface Distribution {
   time: string
-  mechanical: number
-  electric: number
+  mechanical: number | null
+  electric: number | null
 }
 
 export interface StationProperties {